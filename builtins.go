@@ -0,0 +1,241 @@
+package heap
+
+type IntElem struct {
+	data int
+	*IndexMixin
+}
+
+func NewElem(data int) *IntElem {
+	return &IntElem{
+		data: data,
+		IndexMixin: &IndexMixin{},
+	}
+}
+
+func NewMaxHeap() *Heap {
+	return MustHeap(func(i *IntElem, j *IntElem) bool {
+		return i.data > j.data
+	})
+}
+
+func NewMinHeap() *Heap {
+	return MustHeap(func(i *IntElem, j *IntElem) bool {
+		return i.data < j.data
+	})
+}
+
+type Int8Elem struct {
+	data int8
+	*IndexMixin
+}
+
+func NewInt8Elem(data int8) *Int8Elem {
+	return &Int8Elem{
+		data: data,
+		IndexMixin: &IndexMixin{},
+	}
+}
+
+func NewMaxInt8Heap() *Heap {
+	return MustHeap(func(i *Int8Elem, j *Int8Elem) bool {
+		return i.data > j.data
+	})
+}
+
+func NewMinInt8Heap() *Heap {
+	return MustHeap(func(i *Int8Elem, j *Int8Elem) bool {
+		return i.data < j.data
+	})
+}
+
+type Int16Elem struct {
+	data int16
+	*IndexMixin
+}
+
+func NewInt16Elem(data int16) *Int16Elem {
+	return &Int16Elem{
+		data: data,
+		IndexMixin: &IndexMixin{},
+	}
+}
+
+func NewMaxInt16Heap() *Heap {
+	return MustHeap(func(i *Int16Elem, j *Int16Elem) bool {
+		return i.data > j.data
+	})
+}
+
+func NewMinInt16Heap() *Heap {
+	return MustHeap(func(i *Int16Elem, j *Int16Elem) bool {
+		return i.data < j.data
+	})
+}
+
+type Int32Elem struct {
+	data int32
+	*IndexMixin
+}
+
+func NewInt32Elem(data int32) *Int32Elem {
+	return &Int32Elem{
+		data: data,
+		IndexMixin: &IndexMixin{},
+	}
+}
+
+func NewMaxInt32Heap() *Heap {
+	return MustHeap(func(i *Int32Elem, j *Int32Elem) bool {
+		return i.data > j.data
+	})
+}
+
+func NewMinInt32Heap() *Heap {
+	return MustHeap(func(i *Int32Elem, j *Int32Elem) bool {
+		return i.data < j.data
+	})
+}
+
+type Int64Elem struct {
+	data int64
+	*IndexMixin
+}
+
+func NewInt64Elem(data int64) *Int64Elem {
+	return &Int64Elem{
+		data: data,
+		IndexMixin: &IndexMixin{},
+	}
+}
+
+func NewMaxInt64Heap() *Heap {
+	return MustHeap(func(i *Int64Elem, j *Int64Elem) bool {
+		return i.data > j.data
+	})
+}
+
+func NewMinInt64Heap() *Heap {
+	return MustHeap(func(i *Int64Elem, j *Int64Elem) bool {
+		return i.data < j.data
+	})
+}
+
+type UintElem struct {
+	data uint
+	*IndexMixin
+}
+
+func NewUintElem(data uint) *UintElem {
+	return &UintElem{
+		data: data,
+		IndexMixin: &IndexMixin{},
+	}
+}
+
+func NewMaxUintHeap() *Heap {
+	return MustHeap(func(i *UintElem, j *UintElem) bool {
+		return i.data > j.data
+	})
+}
+
+func NewMinUintHeap() *Heap {
+	return MustHeap(func(i *UintElem, j *UintElem) bool {
+		return i.data < j.data
+	})
+}
+
+type Uint8Elem struct {
+	data uint8
+	*IndexMixin
+}
+
+func NewUint8Elem(data uint8) *Uint8Elem {
+	return &Uint8Elem{
+		data: data,
+		IndexMixin: &IndexMixin{},
+	}
+}
+
+func NewMaxUint8Heap() *Heap {
+	return MustHeap(func(i *Uint8Elem, j *Uint8Elem) bool {
+		return i.data > j.data
+	})
+}
+
+func NewMinUint8Heap() *Heap {
+	return MustHeap(func(i *Uint8Elem, j *Uint8Elem) bool {
+		return i.data < j.data
+	})
+}
+
+type Uint16Elem struct {
+	data uint16
+	*IndexMixin
+}
+
+func NewUint16Elem(data uint16) *Uint16Elem {
+	return &Uint16Elem{
+		data: data,
+		IndexMixin: &IndexMixin{},
+	}
+}
+
+func NewMaxUint16Heap() *Heap {
+	return MustHeap(func(i *Uint16Elem, j *Uint16Elem) bool {
+		return i.data > j.data
+	})
+}
+
+func NewMinUint16Heap() *Heap {
+	return MustHeap(func(i *Uint16Elem, j *Uint16Elem) bool {
+		return i.data < j.data
+	})
+}
+
+type Uint32Elem struct {
+	data uint32
+	*IndexMixin
+}
+
+func NewUint32Elem(data uint32) *Uint32Elem {
+	return &Uint32Elem{
+		data: data,
+		IndexMixin: &IndexMixin{},
+	}
+}
+
+func NewMaxUint32Heap() *Heap {
+	return MustHeap(func(i *Uint32Elem, j *Uint32Elem) bool {
+		return i.data > j.data
+	})
+}
+
+func NewMinUint32Heap() *Heap {
+	return MustHeap(func(i *Uint32Elem, j *Uint32Elem) bool {
+		return i.data < j.data
+	})
+}
+
+type Uint64Elem struct {
+	data uint64
+	*IndexMixin
+}
+
+func NewUint64Elem(data uint64) *Uint64Elem {
+	return &Uint64Elem{
+		data: data,
+		IndexMixin: &IndexMixin{},
+	}
+}
+
+func NewMaxUint64Heap() *Heap {
+	return MustHeap(func(i *Uint64Elem, j *Uint64Elem) bool {
+		return i.data > j.data
+	})
+}
+
+func NewMinUint64Heap() *Heap {
+	return MustHeap(func(i *Uint64Elem, j *Uint64Elem) bool {
+		return i.data < j.data
+	})
+}
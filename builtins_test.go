@@ -0,0 +1,111 @@
+package heap
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestInt64HeapsBeyondInt32(t *testing.T) {
+	values := []int64{math.MaxInt32 + 2, -3, math.MaxInt64, math.MaxInt32 + 1, math.MinInt64, 0}
+	for _, test := range []struct {
+		name string
+		h    *Heap
+		want []int64
+	}{
+		{"max", NewMaxInt64Heap(), []int64{math.MaxInt64, math.MaxInt32 + 2, math.MaxInt32 + 1, 0, -3, math.MinInt64}},
+		{"min", NewMinInt64Heap(), []int64{math.MinInt64, -3, 0, math.MaxInt32 + 1, math.MaxInt32 + 2, math.MaxInt64}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for _, v := range values {
+				test.h.Put(NewInt64Elem(v))
+			}
+			var got []int64
+			for test.h.Len() > 0 {
+				var elem Int64Elem
+				test.h.Get(&elem)
+				got = append(got, elem.data)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+// drainData pops every element of h and returns their data fields.
+func drainData(h *Heap) []interface{} {
+	var ret []interface{}
+	for h.Len() > 0 {
+		switch elem := h.pop().(type) {
+		case *Int8Elem:
+			ret = append(ret, elem.data)
+		case *Int16Elem:
+			ret = append(ret, elem.data)
+		case *Int32Elem:
+			ret = append(ret, elem.data)
+		case *UintElem:
+			ret = append(ret, elem.data)
+		case *Uint8Elem:
+			ret = append(ret, elem.data)
+		case *Uint16Elem:
+			ret = append(ret, elem.data)
+		case *Uint32Elem:
+			ret = append(ret, elem.data)
+		case *Uint64Elem:
+			ret = append(ret, elem.data)
+		}
+	}
+	return ret
+}
+
+func TestSizedIntegerHeaps(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		min, max *Heap
+		elems    []interface{}
+		ordered  []interface{}
+	}{
+		{"int8", NewMinInt8Heap(), NewMaxInt8Heap(),
+			[]interface{}{NewInt8Elem(math.MaxInt8), NewInt8Elem(math.MinInt8), NewInt8Elem(1), NewInt8Elem(math.MaxInt8 - 1)},
+			[]interface{}{int8(math.MinInt8), int8(1), int8(math.MaxInt8 - 1), int8(math.MaxInt8)}},
+		{"int16", NewMinInt16Heap(), NewMaxInt16Heap(),
+			[]interface{}{NewInt16Elem(math.MaxInt16), NewInt16Elem(math.MinInt16), NewInt16Elem(1), NewInt16Elem(math.MaxInt16 - 1)},
+			[]interface{}{int16(math.MinInt16), int16(1), int16(math.MaxInt16 - 1), int16(math.MaxInt16)}},
+		{"int32", NewMinInt32Heap(), NewMaxInt32Heap(),
+			[]interface{}{NewInt32Elem(math.MaxInt32), NewInt32Elem(math.MinInt32), NewInt32Elem(1), NewInt32Elem(math.MaxInt32 - 1)},
+			[]interface{}{int32(math.MinInt32), int32(1), int32(math.MaxInt32 - 1), int32(math.MaxInt32)}},
+		{"uint", NewMinUintHeap(), NewMaxUintHeap(),
+			[]interface{}{NewUintElem(math.MaxUint), NewUintElem(0), NewUintElem(1), NewUintElem(math.MaxUint - 1)},
+			[]interface{}{uint(0), uint(1), uint(math.MaxUint - 1), uint(math.MaxUint)}},
+		{"uint8", NewMinUint8Heap(), NewMaxUint8Heap(),
+			[]interface{}{NewUint8Elem(math.MaxUint8), NewUint8Elem(0), NewUint8Elem(1), NewUint8Elem(math.MaxUint8 - 1)},
+			[]interface{}{uint8(0), uint8(1), uint8(math.MaxUint8 - 1), uint8(math.MaxUint8)}},
+		{"uint16", NewMinUint16Heap(), NewMaxUint16Heap(),
+			[]interface{}{NewUint16Elem(math.MaxUint16), NewUint16Elem(0), NewUint16Elem(1), NewUint16Elem(math.MaxUint16 - 1)},
+			[]interface{}{uint16(0), uint16(1), uint16(math.MaxUint16 - 1), uint16(math.MaxUint16)}},
+		{"uint32", NewMinUint32Heap(), NewMaxUint32Heap(),
+			[]interface{}{NewUint32Elem(math.MaxUint32), NewUint32Elem(0), NewUint32Elem(1), NewUint32Elem(math.MaxUint32 - 1)},
+			[]interface{}{uint32(0), uint32(1), uint32(math.MaxUint32 - 1), uint32(math.MaxUint32)}},
+		{"uint64", NewMinUint64Heap(), NewMaxUint64Heap(),
+			[]interface{}{NewUint64Elem(math.MaxUint64), NewUint64Elem(0), NewUint64Elem(1), NewUint64Elem(math.MaxUint64 - 1)},
+			[]interface{}{uint64(0), uint64(1), uint64(math.MaxUint64 - 1), uint64(math.MaxUint64)}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for _, elem := range test.elems {
+				test.min.Put(elem)
+				test.max.Put(elem)
+			}
+			if got := drainData(test.min); !reflect.DeepEqual(got, test.ordered) {
+				t.Fatalf("min-heap: expected %v, got %v", test.ordered, got)
+			}
+			reversed := make([]interface{}, len(test.ordered))
+			for index, v := range test.ordered {
+				reversed[len(reversed)-1-index] = v
+			}
+			if got := drainData(test.max); !reflect.DeepEqual(got, reversed) {
+				t.Fatalf("max-heap: expected %v, got %v", reversed, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package heap
+
+import (
+	"container/list"
+	"reflect"
+)
+
+type cmpKey struct {
+	a, b reflect.Value
+}
+
+type cmpEntry struct {
+	key    cmpKey
+	result bool
+}
+
+// cmpCache is a least recently used cache of comparator results.
+type cmpCache struct {
+	size    int
+	order   *list.List
+	entries map[cmpKey]*list.Element
+	// byElem indexes the entries by both of their elements
+	byElem map[reflect.Value]map[*list.Element]struct{}
+}
+
+func newCmpCache(size int) *cmpCache {
+	return &cmpCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[cmpKey]*list.Element, size),
+		byElem:  make(map[reflect.Value]map[*list.Element]struct{}),
+	}
+}
+
+func (c *cmpCache) get(key cmpKey) (result, ok bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return false, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cmpEntry).result, true
+}
+
+func (c *cmpCache) put(key cmpKey, result bool) {
+	elem := c.order.PushFront(&cmpEntry{key, result})
+	c.entries[key] = elem
+	for _, v := range []reflect.Value{key.a, key.b} {
+		if nil == c.byElem[v] {
+			c.byElem[v] = make(map[*list.Element]struct{})
+		}
+		c.byElem[v][elem] = struct{}{}
+	}
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *cmpCache) remove(elem *list.Element) {
+	key := elem.Value.(*cmpEntry).key
+	c.order.Remove(elem)
+	delete(c.entries, key)
+	for _, v := range []reflect.Value{key.a, key.b} {
+		delete(c.byElem[v], elem)
+		if len(c.byElem[v]) == 0 {
+			delete(c.byElem, v)
+		}
+	}
+}
+
+// forget drops every cached result involving v.
+func (c *cmpCache) forget(v reflect.Value) {
+	for elem := range c.byElem[v] {
+		c.remove(elem)
+	}
+}
+
+// retain drops the cached results of all elements for which keep is false.
+func (c *cmpCache) retain(keep func(reflect.Value) bool) {
+	for v := range c.byElem {
+		if !keep(v) {
+			c.forget(v)
+		}
+	}
+}
+
+func (c *cmpCache) flush() {
+	c.order.Init()
+	clear(c.entries)
+	clear(c.byElem)
+}
+
+// WithComparatorCache remembers the results of the last size comparisons,
+// keyed by the pair of elements. Results for an element are dropped when it
+// leaves the heap or is passed to Update, Reschedule or BatchFix; after
+// mutating elements still in the heap in any other way call
+// FlushComparatorCache.
+func WithComparatorCache(size int) Option {
+	return func(h *Heap) {
+		if size > 0 {
+			h.cache = newCmpCache(size)
+		}
+	}
+}
+
+func (h *Heap) FlushComparatorCache() {
+	if nil != h.cache {
+		h.cache.flush()
+	}
+}
+
+func (h *Heap) forget(v reflect.Value) {
+	if nil != h.cache {
+		h.cache.forget(v)
+	}
+}
+
+// call invokes the comparator, consulting the cache if there is one.
+func (h *Heap) call(a, b reflect.Value) bool {
+	if nil == h.cache {
+		return h.invoke(a, b)
+	}
+	key := cmpKey{a, b}
+	if result, ok := h.cache.get(key); ok {
+		return result
+	}
+	result := h.invoke(a, b)
+	h.cache.put(key, result)
+	return result
+}
@@ -0,0 +1,108 @@
+package heap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func drainInts(h *Heap) []int {
+	var ret []int
+	for h.Len() > 0 {
+		var elem IntElem
+		h.Get(&elem)
+		ret = append(ret, elem.data)
+	}
+	return ret
+}
+
+func TestComparatorCachePopChangePut(t *testing.T) {
+	h, err := NewHeap(func(a, b *IntElem) bool {
+		return a.data < b.data
+	}, WithComparatorCache(100))
+	if nil != err {
+		t.Fatal(err)
+	}
+	one := NewElem(1)
+	h.Put(one)
+	h.Put(NewElem(2))
+	h.Put(NewElem(3))
+
+	if top := h.pop(); top != one {
+		t.Fatalf("expected 1 on top, got %v", top)
+	}
+	one.data = 10
+	h.Put(one)
+
+	if got, want := drainInts(h), []int{2, 3, 10}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestComparatorCacheRotate(t *testing.T) {
+	h, err := NewHeap(func(a, b *IntElem) bool {
+		return a.data < b.data
+	}, WithComparatorCache(100))
+	if nil != err {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 5; i++ {
+		h.Put(NewElem(i))
+	}
+	h.Rotate(2, func(item interface{}) interface{} {
+		item.(*IntElem).data += 100
+		return item
+	})
+
+	if got, want := drainInts(h), []int{3, 4, 5, 101, 102}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestComparatorCacheSwapTopAndReset(t *testing.T) {
+	h, err := NewHeap(func(a, b *IntElem) bool {
+		return a.data < b.data
+	}, WithComparatorCache(100))
+	if nil != err {
+		t.Fatal(err)
+	}
+	one, two := NewElem(1), NewElem(2)
+	h.Put(one)
+	h.Put(two)
+	h.Put(NewElem(3))
+
+	old, _ := h.SwapTop(NewElem(4))
+	old.(*IntElem).data = 10
+	h.Put(one)
+	if got, want := drainInts(h), []int{2, 3, 4, 10}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after SwapTop: expected %v, got %v", want, got)
+	}
+
+	h.Put(one)
+	h.Put(two)
+	h.Reset()
+	one.data, two.data = 2, 1
+	h.Put(one)
+	h.Put(two)
+	if got, want := drainInts(h), []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("after Reset: expected %v, got %v", want, got)
+	}
+}
+
+func TestComparatorCacheBounded(t *testing.T) {
+	h, err := NewHeap(func(a, b *IntElem) bool {
+		return a.data < b.data
+	}, WithComparatorCache(8))
+	if nil != err {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		h.Put(NewElem(i % 17))
+	}
+	if h.cache.order.Len() > 8 || len(h.cache.entries) > 8 {
+		t.Fatalf("cache grew beyond its size: %d entries", len(h.cache.entries))
+	}
+	drainInts(h)
+	if len(h.cache.entries) != 0 || len(h.cache.byElem) != 0 {
+		t.Fatalf("cache keeps %d entries after draining the heap", len(h.cache.entries))
+	}
+}
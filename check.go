@@ -0,0 +1,161 @@
+package heap
+
+import (
+	coheap "container/heap"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// HealthCheck verifies the heap invariant and the consistency of the lookup
+// map and element indices. All violations found are joined into the returned
+// error.
+func (h *Heap) HealthCheck() error {
+	var errs []error
+	for index, obj := range h.objects {
+		if !obj.IsValid() {
+			errs = append(errs, fmt.Errorf("zero value at index %d", index))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for index := 1; index < len(h.objects); index++ {
+		parent := (index - 1) / 2
+		if h.Less(index, parent) {
+			errs = append(errs, fmt.Errorf("heap invariant violated between index %d and its parent %d", index, parent))
+		}
+	}
+
+	if !h.noLookup && len(h.objects) != len(h.lookup) {
+		errs = append(errs, fmt.Errorf("%d elements but %d lookup entries", len(h.objects), len(h.lookup)))
+	}
+	for obj, index := range h.lookup {
+		if index < 0 || index >= len(h.objects) || h.objects[index] != obj {
+			errs = append(errs, fmt.Errorf("lookup entry for %v points to wrong index %d", obj.Interface(), index))
+		}
+	}
+
+	if h.indexer {
+		for index, obj := range h.objects {
+			if got := obj.Interface().(Indexer).GetIndex(); got != index {
+				errs = append(errs, fmt.Errorf("element at index %d reports index %d", index, got))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RunGC deletes lookup entries that no longer point at their element and
+// returns how many were removed.
+func (h *Heap) RunGC() int {
+	removed := 0
+	for obj, index := range h.lookup {
+		if index < 0 || index >= len(h.objects) || h.objects[index] != obj {
+			delete(h.lookup, obj)
+			removed++
+		}
+	}
+	h.syncStats()
+	return removed
+}
+
+// CompactCopy returns a copy of h whose backing slice and lookup map are
+// sized for exactly its elements. Replace h with the copy and drop every
+// reference to h to let the garbage collector reclaim the old storage. The
+// copy shares the elements and keeps the whole configuration of h, including
+// index tracking, the comparator cache, logger, event bus and metrics, so h
+// must not be used afterwards.
+func (h *Heap) CompactCopy() *Heap {
+	c := h.clone()
+	coheap.Init(c)
+	c.indexer = h.indexer
+	c.strictTypes = h.strictTypes
+	if nil != h.cache {
+		c.cache = newCmpCache(h.cache.size)
+	}
+	c.logger = h.logger
+	c.bus = h.bus
+	c.metrics = h.metrics
+	c.syncStats()
+	return c
+}
+
+// VerifyLookup lists every discrepancy between the lookup map and the
+// objects slice. An empty result means both agree.
+func (h *Heap) VerifyLookup() []string {
+	var issues []string
+	if h.noLookup {
+		return issues
+	}
+	for index, obj := range h.objects {
+		stored, ok := h.lookup[obj]
+		if !ok {
+			issues = append(issues, fmt.Sprintf("element %v at index %d is missing from lookup", obj.Interface(), index))
+		} else if stored != index {
+			issues = append(issues, fmt.Sprintf("element %v at index %d is stored with index %d", obj.Interface(), index, stored))
+		}
+	}
+	for obj, stored := range h.lookup {
+		if stored < 0 || stored >= len(h.objects) || h.objects[stored] != obj {
+			found := false
+			for _, candidate := range h.objects {
+				if candidate == obj {
+					found = true
+					break
+				}
+			}
+			if !found {
+				issues = append(issues, fmt.Sprintf("lookup entry %v with index %d is not in the heap", obj.Interface(), stored))
+			}
+		}
+	}
+	return issues
+}
+
+// ValidateComparator checks on samples that fn is a strict weak order: no
+// element is less than itself, less(a, b) rules out less(b, a) and less is
+// transitive. It runs in O(n³) and is meant for tests.
+func ValidateComparator(fn interface{}, samples []interface{}) error {
+	h := &Heap{}
+	if err := h.checkAndSetFn(fn); nil != err {
+		return err
+	}
+	values := make([]reflect.Value, len(samples))
+	for index, item := range samples {
+		if reflect.TypeOf(item) != h.dataType {
+			return ErrTypeMismatch
+		}
+		values[index] = reflect.ValueOf(item)
+	}
+	less := func(a, b reflect.Value) bool {
+		return h.cmpFn.Call([]reflect.Value{a, b})[0].Bool()
+	}
+	for _, a := range values {
+		if less(a, a) {
+			return fmt.Errorf("%w: %v", ErrReflexiveComparator, a.Interface())
+		}
+	}
+	for _, a := range values {
+		for _, b := range values {
+			if less(a, b) && less(b, a) {
+				return fmt.Errorf("%w: %v and %v are both less than each other", ErrInconsistentComparator, a.Interface(), b.Interface())
+			}
+		}
+	}
+	for _, a := range values {
+		for _, b := range values {
+			if !less(a, b) {
+				continue
+			}
+			for _, c := range values {
+				if less(b, c) && !less(a, c) {
+					return fmt.Errorf("%w: %v < %v < %v but not %v < %v", ErrIntransitiveComparator, a.Interface(), b.Interface(), c.Interface(), a.Interface(), c.Interface())
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package heap
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCompactCopy(t *testing.T) {
+	h := NewMaxHeap()
+	for i := 0; i < 100000; i++ {
+		h.Put(NewElem(i))
+	}
+	for i := 0; i < 99000; i++ {
+		h.pop()
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	h = h.CompactCopy()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	mustBeHealthy(t, h)
+	if h.Len() != 1000 || cap(h.objects) != 1000 {
+		t.Fatalf("expected 1000 elements with capacity 1000, got %d and %d", h.Len(), cap(h.objects))
+	}
+	if after.HeapAlloc >= before.HeapAlloc {
+		t.Fatalf("heap memory did not shrink: %d before, %d after", before.HeapAlloc, after.HeapAlloc)
+	}
+}
+
+func TestCompactCopyKeepsConfiguration(t *testing.T) {
+	var log strings.Builder
+	metrics := &countingMetrics{}
+	var evicted []interface{}
+	h, err := NewHeapWithEvictCallback(func(a, b *IntElem) bool {
+		return a.data > b.data
+	}, 2, func(item interface{}) {
+		evicted = append(evicted, item)
+	}, WithComparatorCache(10), WithLogger(&log), WithMetrics(metrics))
+	if nil != err {
+		t.Fatal(err)
+	}
+	h.Put(NewElem(5))
+	h.Put(NewElem(1))
+
+	c := h.CompactCopy()
+	log.Reset()
+	c.Put(NewElem(3))
+	c.Put(NewElem(7))
+	mustBeHealthy(t, c)
+	if c.Len() != 2 || len(evicted) != 2 {
+		t.Fatalf("expected 2 elements and 2 evictions, got %d and %d", c.Len(), len(evicted))
+	}
+	if !c.IsIndexTrackingEnabled() || nil == c.cache {
+		t.Fatal("index tracking or the comparator cache was dropped")
+	}
+	if metrics.pushes != 4 || !strings.Contains(log.String(), "PUT") {
+		t.Fatalf("hooks were dropped: %d pushes observed, log %q", metrics.pushes, log.String())
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		corrupt func(h *Heap)
+		want    []string
+	}{
+		{"healthy", func(h *Heap) {}, nil},
+		{"zero value", func(h *Heap) {
+			h.objects[1] = reflect.Value{}
+		}, []string{"zero value at index 1"}},
+		{"invariant", func(h *Heap) {
+			h.objects[0], h.objects[2] = h.objects[2], h.objects[0]
+		}, []string{"heap invariant violated", "points to wrong index", "reports index"}},
+		{"stale lookup", func(h *Heap) {
+			h.lookup[reflect.ValueOf(NewElem(0))] = 7
+		}, []string{"4 elements but 5 lookup entries", "points to wrong index 7"}},
+		{"indexer", func(h *Heap) {
+			h.objects[3].Interface().(Indexer).SetIndex(0)
+		}, []string{"element at index 3 reports index 0"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			h := newIntMaxHeap(t, 4, 3, 2, 1)
+			test.corrupt(h)
+			err := h.HealthCheck()
+			if nil == test.want {
+				if nil != err {
+					t.Fatal(err)
+				}
+				return
+			}
+			if nil == err {
+				t.Fatal("expected the corruption to be reported")
+			}
+			for _, want := range test.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected %q in %q", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRunGC(t *testing.T) {
+	h := newIntMaxHeap(t, 1, 2, 3)
+	moved := h.objects[1]
+	stale := []reflect.Value{reflect.ValueOf(NewElem(4)), reflect.ValueOf(NewElem(5))}
+	h.lookup[stale[0]] = 1
+	h.lookup[stale[1]] = 99
+	h.lookup[moved] = 2
+	if removed := h.RunGC(); removed != 3 {
+		t.Fatalf("expected 3 stale entries removed, got %d", removed)
+	}
+	for _, v := range stale {
+		if _, ok := h.lookup[v]; ok {
+			t.Fatal("expected stale entries to be gone")
+		}
+	}
+	if stats := h.Stats(); stats.LookupSize != 2 {
+		t.Fatalf("expected Stats to report 2 lookup entries, got %d", stats.LookupSize)
+	}
+	if removed := h.RunGC(); removed != 0 {
+		t.Fatalf("expected a second run to find nothing, got %d", removed)
+	}
+}
+
+func TestVerifyLookup(t *testing.T) {
+	h := newIntMaxHeap(t, 3, 2, 1)
+	if issues := h.VerifyLookup(); len(issues) != 0 {
+		t.Fatalf("expected no issues, got %v", issues)
+	}
+	missing, moved := h.objects[1], h.objects[2]
+	delete(h.lookup, missing)
+	h.lookup[moved] = 0
+	h.lookup[reflect.ValueOf(NewElem(4))] = 1
+	issues := h.VerifyLookup()
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %v", issues)
+	}
+	for _, want := range []string{"is missing from lookup", "is stored with index 0", "is not in the heap"} {
+		found := false
+		for _, issue := range issues {
+			found = found || strings.Contains(issue, want)
+		}
+		if !found {
+			t.Errorf("expected an issue containing %q in %v", want, issues)
+		}
+	}
+
+	disabled := MustHeap(lessIntElem, WithoutLookup())
+	disabled.Put(NewElem(1))
+	if issues := disabled.VerifyLookup(); len(issues) != 0 {
+		t.Fatalf("expected no issues without a lookup map, got %v", issues)
+	}
+}
+
+func TestValidateComparator(t *testing.T) {
+	samples := []interface{}{NewElem(1), NewElem(2), NewElem(3), NewElem(2)}
+	for _, test := range []struct {
+		name string
+		fn   func(a, b *IntElem) bool
+		err  error
+	}{
+		{"strict", func(a, b *IntElem) bool { return a.data < b.data }, nil},
+		{"reflexive", func(a, b *IntElem) bool { return a.data <= b.data }, ErrReflexiveComparator},
+		{"inconsistent", func(a, b *IntElem) bool { return a != b }, ErrInconsistentComparator},
+		// rock, paper, scissors
+		{"intransitive", func(a, b *IntElem) bool { return (a.data+1)%3 == b.data%3 }, ErrIntransitiveComparator},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidateComparator(test.fn, samples); !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+	if err := ValidateComparator(lessIntElem, []interface{}{1}); !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+}
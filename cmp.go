@@ -0,0 +1,24 @@
+package heap
+
+// NewCmpHeap builds a min-heap from a three-way comparator in the style of
+// cmp.Compare. T must be a pointer type.
+func NewCmpHeap[T any](compare func(a, b T) int) *Heap {
+	return MustHeap(func(a, b T) bool {
+		return compare(a, b) < 0
+	})
+}
+
+func NewCmpMaxHeap[T any](compare func(a, b T) int) *Heap {
+	return MustHeap(func(a, b T) bool {
+		return compare(a, b) > 0
+	})
+}
+
+// Reversed returns a comparator ordering the opposite way. It swaps the
+// arguments rather than negating the result, which would overflow for
+// math.MinInt.
+func Reversed[T any](compare func(a, b T) int) func(a, b T) int {
+	return func(a, b T) int {
+		return compare(b, a)
+	}
+}
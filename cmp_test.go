@@ -0,0 +1,61 @@
+package heap
+
+import (
+	"cmp"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func compareElems(a, b *IntElem) int {
+	return cmp.Compare(a.data, b.data)
+}
+
+func TestNewCmpHeap(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		h    *Heap
+		want []int
+	}{
+		{"min", NewCmpHeap(compareElems), []int{1, 2, 3, 4}},
+		{"max", NewCmpMaxHeap(compareElems), []int{4, 3, 2, 1}},
+		{"reversed", NewCmpHeap(Reversed(compareElems)), []int{4, 3, 2, 1}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for _, v := range []int{3, 1, 4, 2} {
+				test.h.Put(NewElem(v))
+			}
+			if got := drainInts(test.h); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReversedMinInt(t *testing.T) {
+	// a comparator may return any negative number, including the one that
+	// cannot be negated
+	extreme := func(a, b *IntElem) int {
+		switch {
+		case a.data < b.data:
+			return math.MinInt
+		case a.data > b.data:
+			return math.MaxInt
+		}
+		return 0
+	}
+	reversed := Reversed(extreme)
+	if got := reversed(NewElem(1), NewElem(2)); got <= 0 {
+		t.Fatalf("expected a positive result, got %d", got)
+	}
+	if got := reversed(NewElem(2), NewElem(1)); got >= 0 {
+		t.Fatalf("expected a negative result, got %d", got)
+	}
+	h := NewCmpHeap(reversed)
+	for _, v := range []int{3, 1, 4, 2} {
+		h.Put(NewElem(v))
+	}
+	if got := drainInts(h); !reflect.DeepEqual(got, []int{4, 3, 2, 1}) {
+		t.Fatalf("expected descending order, got %v", got)
+	}
+}
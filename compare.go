@@ -0,0 +1,84 @@
+package heap
+
+import (
+	coheap "container/heap"
+	"reflect"
+)
+
+// Equal reports whether both heaps hold deeply equal elements in the same
+// priority order. Index bookkeeping of embedded IndexMixins is ignored.
+func (h *Heap) Equal(other *Heap) bool {
+	if h.dataType != other.dataType || h.Len() != other.Len() {
+		return false
+	}
+	a, b := h.clone(), other.clone()
+	for a.Len() > 0 {
+		if !equalElems(reflect.ValueOf(coheap.Pop(a)), reflect.ValueOf(coheap.Pop(b))) {
+			return false
+		}
+	}
+	return true
+}
+
+// EqualUnordered reports whether both heaps hold the same elements, compared
+// by identity, regardless of their order.
+func (h *Heap) EqualUnordered(other *Heap) bool {
+	if h.dataType != other.dataType || h.Len() != other.Len() {
+		return false
+	}
+	counts := make(map[reflect.Value]int, len(other.objects))
+	for _, obj := range other.objects {
+		counts[obj]++
+	}
+	for _, obj := range h.objects {
+		if counts[obj] == 0 {
+			return false
+		}
+		counts[obj]--
+	}
+	return true
+}
+
+func equalElems(a, b reflect.Value) bool {
+	if a.IsNil() || b.IsNil() {
+		return a.IsNil() == b.IsNil()
+	}
+	x, y := reflect.New(a.Type().Elem()), reflect.New(b.Type().Elem())
+	x.Elem().Set(a.Elem())
+	y.Elem().Set(b.Elem())
+	renewIndexMixins(x.Elem())
+	renewIndexMixins(y.Elem())
+	return reflect.DeepEqual(x.Interface(), y.Interface())
+}
+
+// Diff returns the elements, compared by identity, that are only part of h and
+// only part of other.
+func (h *Heap) Diff(other *Heap) (onlyInH, onlyInOther []interface{}, err error) {
+	if h.dataType != other.dataType {
+		return nil, nil, ErrTypeMismatch
+	}
+	inH, inOther := h.members(), other.members()
+	for _, obj := range h.objects {
+		if _, ok := inOther[obj]; !ok {
+			onlyInH = append(onlyInH, obj.Interface())
+		}
+	}
+	for _, obj := range other.objects {
+		if _, ok := inH[obj]; !ok {
+			onlyInOther = append(onlyInOther, obj.Interface())
+		}
+	}
+	return onlyInH, onlyInOther, nil
+}
+
+// members returns the lookup map, or an equivalent one if lookup is disabled.
+func (h *Heap) members() map[reflect.Value]int {
+	if !h.noLookup {
+		return h.lookup
+	}
+	m := make(map[reflect.Value]int, len(h.objects))
+	for index, obj := range h.objects {
+		m[obj] = index
+	}
+	return m
+}
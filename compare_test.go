@@ -0,0 +1,85 @@
+package heap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	h := newIntMaxHeap(t, 1, 5, 3, 5)
+	same := newIntMaxHeap(t, 5, 3, 5, 1)
+	if !h.Equal(same) || !same.Equal(h) {
+		t.Fatal("expected heaps with equal values in any insertion order to be equal")
+	}
+	if h.Equal(newIntMaxHeap(t, 1, 5, 3, 4)) {
+		t.Fatal("expected heaps with different values to differ")
+	}
+	if h.Equal(newIntMaxHeap(t, 1, 5, 3)) {
+		t.Fatal("expected heaps of different length to differ")
+	}
+	if h.Equal(NewMaxInt64Heap()) || NewMinHeap().Equal(NewMinInt64Heap()) {
+		t.Fatal("expected heaps of different types to differ")
+	}
+	if h.Len() != 4 || same.Len() != 4 {
+		t.Fatal("expected Equal to leave both heaps intact")
+	}
+	mustBeHealthy(t, h)
+}
+
+func TestEqualUnordered(t *testing.T) {
+	a, b := NewElem(1), NewElem(2)
+	h, other := NewMaxHeap(), NewMinHeap()
+	for _, item := range []*IntElem{a, b} {
+		h.Put(item)
+		other.Put(item)
+	}
+	if !h.EqualUnordered(other) {
+		t.Fatal("expected the same elements in another order to be equal")
+	}
+	if h.EqualUnordered(newIntMaxHeap(t, 1, 2)) {
+		t.Fatal("expected equal values of other elements to differ")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	shared := []*IntElem{NewElem(1), NewElem(2)}
+	onlyA := []*IntElem{NewElem(3), NewElem(4)}
+	// an equal value is still another element
+	onlyB := []*IntElem{NewElem(5), NewElem(3)}
+	a, b := NewMaxHeap(), NewMinHeap()
+	for _, item := range append(append([]*IntElem{}, shared...), onlyA...) {
+		a.Put(item)
+	}
+	for _, item := range append(append([]*IntElem{}, shared...), onlyB...) {
+		b.Put(item)
+	}
+
+	inA, inB, err := a.Diff(b)
+	if nil != err {
+		t.Fatal(err)
+	}
+	set := func(items []interface{}) map[interface{}]bool {
+		m := make(map[interface{}]bool)
+		for _, item := range items {
+			m[item] = true
+		}
+		return m
+	}
+	if want := toInterfaces(onlyA); len(inA) != len(want) || !reflect.DeepEqual(set(inA), set(want)) {
+		t.Fatalf("expected %v only in a, got %v", elemValues(want), elemValues(inA))
+	}
+	if want := toInterfaces(onlyB); len(inB) != len(want) || !reflect.DeepEqual(set(inB), set(want)) {
+		t.Fatalf("expected %v only in b, got %v", elemValues(want), elemValues(inB))
+	}
+	if _, _, err := a.Diff(NewMinInt64Heap()); err != ErrTypeMismatch {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+}
+
+func toInterfaces(items []*IntElem) []interface{} {
+	ret := make([]interface{}, len(items))
+	for index, item := range items {
+		ret[index] = item
+	}
+	return ret
+}
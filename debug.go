@@ -0,0 +1,28 @@
+//go:build debug
+
+package heap
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// verifyLess panics with ErrInconsistentComparator if the comparator changes
+// its mind when asked twice or claims both elements to be less than each
+// other, and with ErrReflexiveComparator if it claims an element to be less
+// than itself. It is only compiled into builds with the debug tag.
+func (h *Heap) verifyLess(i, j int) {
+	a, b := h.objects[i], h.objects[j]
+	for _, v := range []reflect.Value{a, b} {
+		if h.cmpFn.Call([]reflect.Value{v, v})[0].Bool() {
+			panic(fmt.Errorf("%w: %v", ErrReflexiveComparator, v.Interface()))
+		}
+	}
+	ret := h.cmpFn.Call([]reflect.Value{a, b})[0].Bool()
+	if ret != h.cmpFn.Call([]reflect.Value{a, b})[0].Bool() {
+		panic(fmt.Errorf("%w: Less(%d, %d) changed its result for %v and %v", ErrInconsistentComparator, i, j, a.Interface(), b.Interface()))
+	}
+	if ret && h.cmpFn.Call([]reflect.Value{b, a})[0].Bool() {
+		panic(fmt.Errorf("%w: Less(%d, %d) and Less(%d, %d) are both true for %v and %v", ErrInconsistentComparator, i, j, j, i, a.Interface(), b.Interface()))
+	}
+}
@@ -0,0 +1,65 @@
+//go:build debug
+
+package heap
+
+import (
+	"errors"
+	"testing"
+)
+
+// mustPanicWith runs fn and fails unless it panics with an error wrapping
+// target.
+func mustPanicWith(t *testing.T, target error, fn func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		err, ok := recover().(error)
+		if !ok || !errors.Is(err, target) {
+			t.Fatalf("expected a panic with %v, got %v", target, err)
+		}
+	}()
+	fn()
+}
+
+func TestVerifyLessBothLess(t *testing.T) {
+	h := MustHeap(func(a, b *IntElem) bool {
+		return a != b
+	})
+	h.Put(NewElem(1))
+	mustPanicWith(t, ErrInconsistentComparator, func() {
+		h.Put(NewElem(2))
+	})
+}
+
+func TestVerifyLessChangingResult(t *testing.T) {
+	calls := 0
+	h := MustHeap(func(a, b *IntElem) bool {
+		if a == b {
+			return false
+		}
+		calls++
+		return calls%2 == 0
+	})
+	h.Put(NewElem(1))
+	mustPanicWith(t, ErrInconsistentComparator, func() {
+		h.Put(NewElem(2))
+	})
+}
+
+func TestVerifyLessConsistent(t *testing.T) {
+	h := NewMinHeap()
+	for _, v := range []int{3, 1, 2, 1} {
+		h.Put(NewElem(v))
+	}
+	mustBeHealthy(t, h)
+}
+
+func TestVerifyLessReflexive(t *testing.T) {
+	h := MustHeap(func(a, b *IntElem) bool {
+		return a.data >= b.data
+	})
+	h.Put(NewElem(1))
+	mustPanicWith(t, ErrReflexiveComparator, func() {
+		h.Put(NewElem(2))
+	})
+}
@@ -0,0 +1,40 @@
+package heap
+
+import (
+	coheap "container/heap"
+	"encoding/gob"
+	"reflect"
+)
+
+// Serialize streams the element count followed by every element to enc. The
+// elements must be encodable by gob; as IndexMixin has no exported fields,
+// types embedding it have to implement gob.GobEncoder and gob.GobDecoder.
+func (h *Heap) Serialize(enc *gob.Encoder) error {
+	if err := enc.Encode(len(h.objects)); nil != err {
+		return err
+	}
+	for _, obj := range h.objects {
+		if err := enc.EncodeValue(obj); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deserialize reads elements written by Serialize and adds them to the heap.
+func (h *Heap) Deserialize(dec *gob.Decoder) error {
+	var count int
+	if err := dec.Decode(&count); nil != err {
+		return err
+	}
+	for i := 0; i < count; i++ {
+		item := reflect.New(h.dataType.Elem())
+		if err := dec.DecodeValue(item); nil != err {
+			return err
+		}
+		h.Push(item.Interface())
+	}
+	coheap.Init(h)
+	h.evict()
+	return nil
+}
@@ -0,0 +1,43 @@
+package heap
+
+import (
+	"encoding/gob"
+	"net"
+	"testing"
+)
+
+func TestSerializeOverPipe(t *testing.T) {
+	byN := func(a, b *gobElem) bool {
+		return a.N < b.N
+	}
+	source := MustHeap(byN)
+	for i := 0; i < 10000; i++ {
+		source.Put(&gobElem{N: i * 7919 % 10000})
+	}
+	writer, reader := net.Pipe()
+	errs := make(chan error, 1)
+	go func() {
+		defer writer.Close()
+		errs <- source.Serialize(gob.NewEncoder(writer))
+	}()
+
+	target := MustHeap(byN)
+	if err := target.Deserialize(gob.NewDecoder(reader)); nil != err {
+		t.Fatal(err)
+	}
+	if err := <-errs; nil != err {
+		t.Fatal(err)
+	}
+	mustBeHealthy(t, target)
+	if target.Len() != 10000 {
+		t.Fatalf("expected 10000 elements, got %d", target.Len())
+	}
+	for want := 0; target.Len() > 0; want++ {
+		if got := target.pop().(*gobElem).N; got != want {
+			t.Fatalf("expected %d, got %d", want, got)
+		}
+	}
+	if source.Len() != 10000 {
+		t.Fatalf("expected Serialize to keep the elements, got %d", source.Len())
+	}
+}
@@ -3,9 +3,39 @@ package heap
 import (
 	"reflect"
 	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"math/bits"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	coheap "container/heap"
 )
 
+var (
+	ErrMustBePointerReceiver = errors.New("elems must have a pointer receiver")
+	ErrNotFound              = errors.New("element not found")
+	ErrTypeMismatch          = errors.New("element type does not match the heap")
+	ErrLookupDisabled        = errors.New("lookup is disabled for this heap")
+	ErrEmptyInterface        = errors.New("elems must not be pointers to the empty interface")
+	ErrTimeout               = errors.New("timed out waiting for an element")
+	ErrVariadicComparator    = errors.New("comparator must not be variadic")
+
+	ErrNonDeterministicComparator = errors.New("comparator returned different results for the same arguments")
+	ErrInconsistentComparator     = errors.New("comparator is inconsistent")
+	ErrReflexiveComparator        = errors.New("comparator reports an element as less than itself")
+	ErrIntransitiveComparator     = errors.New("comparator is not transitive")
+	ErrEmptyHeap                  = errors.New("heap is empty")
+	ErrStopped                    = errors.New("heap has been stopped")
+	ErrComparatorTimeout          = errors.New("comparator did not return in time")
+	ErrInsertionTrackingDisabled  = errors.New("insertion tracking is disabled for this heap")
+	ErrEvictingTransaction        = errors.New("transaction deletes or updates an element after a put that may evict it")
+)
+
 type Indexer interface {
 	GetIndex() int
 	SetIndex(int)
@@ -25,29 +55,113 @@ func (i *IndexMixin) SetIndex(index int) {
 
 type Heap struct {
 	objects []reflect.Value
+	length  atomic.Int64
+
+	pushCount   atomic.Uint64
+	popCount    atomic.Uint64
+	deleteCount atomic.Uint64
+
+	// mirrors of cap(objects), len(lookup) and indexer for Stats
+	capacity   atomic.Int64
+	lookupSize atomic.Int64
+	indexing   atomic.Bool
+
+	// unix nano timestamps of the last user level push and pop
+	lastPush atomic.Int64
+	lastPop  atomic.Int64
 
 	cmpFn reflect.Value
 	dataType reflect.Type
 
 	indexer bool
 
-	lookup map[reflect.Value]int
+	immutableCmp bool
+	strictTypes  bool
+
+	seed int64
+	rng  *rand.Rand
+	ties map[reflect.Value]uint64
+
+	// seq numbers elements in insertion order, seqs is nil unless
+	// WithInsertionTracking is used
+	seq  uint64
+	seqs map[reflect.Value]uint64
+
+	cache *cmpCache
+
+	compareTimeout time.Duration
+
+	maxSize int
+	onEvict func(interface{})
+
+	// pinned takes precedence over every other element, see MoveToFront
+	pinned reflect.Value
+
+	lookup   map[reflect.Value]int
+	noLookup bool
+
+	logger  io.Writer
+	bus     EventBus
+	metrics Metrics
 }
 
-func NewHeap(compareFn interface{}) (*Heap, error) {
+func NewHeap(compareFn interface{}, opts ...Option) (*Heap, error) {
 	h := &Heap{
 		objects:make([]reflect.Value, 0),
 		lookup:make(map[reflect.Value]int),
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
 	if err := h.checkAndSetFn(compareFn); nil != err {
 		return nil, err
 	}
+	h.syncStats()
+	h.resetTimestamps()
+
+	return h, nil
+}
+
+// NewHeapWithEvictCallback creates a heap holding at most maxSize elements.
+// Whenever Put or one of the bulk operations exceeds that size, the elements
+// with the lowest priority are removed and passed to onEvict, if it is not
+// nil, before the call returns. Finding the lowest priority element scans the
+// leaves, so every Put on a full heap costs O(n).
+func NewHeapWithEvictCallback(compareFn interface{}, maxSize int, onEvict func(interface{}), opts ...Option) (*Heap, error) {
+	if maxSize <= 0 {
+		return nil, errors.New("maxSize must be positive")
+	}
+	h, err := NewHeap(compareFn, opts...)
+	if nil != err {
+		return nil, err
+	}
+	h.maxSize = maxSize
+	h.onEvict = onEvict
+	return h, nil
+}
 
+// NewHeapForType creates a heap for elements of elementType, which must be a
+// pointer type assignable to the parameters of compareFn. It is meant for
+// generated code that knows the element type but has no value of it.
+func NewHeapForType(elementType reflect.Type, compareFn interface{}, opts ...Option) (*Heap, error) {
+	if nil == elementType || elementType.Kind() != reflect.Ptr {
+		return nil, ErrMustBePointerReceiver
+	}
+	h, err := NewHeap(compareFn, opts...)
+	if nil != err {
+		return nil, err
+	}
+	if !elementType.AssignableTo(h.dataType) {
+		return nil, ErrTypeMismatch
+	}
+	h.dataType = elementType
+	h.indexer = elementType.Implements(reflect.TypeOf((*Indexer)(nil)).Elem())
+	h.syncStats()
 	return h, nil
 }
 
-func MustHeap(compareFn interface{}) *Heap {
-	h, err := NewHeap(compareFn)
+func MustHeap(compareFn interface{}, opts ...Option) *Heap {
+	h, err := NewHeap(compareFn, opts...)
 	if nil != err {
 		panic(err)
 	}
@@ -60,6 +174,9 @@ func (h *Heap) checkAndSetFn(compareFn interface{}) error {
 	if to.Kind() != reflect.Func {
 		return errors.New("not a function")
 	}
+	if to.IsVariadic() {
+		return ErrVariadicComparator
+	}
 	if to.NumOut() != 1 {
 		return errors.New("invalid amount of return params")
 	}
@@ -72,16 +189,26 @@ func (h *Heap) checkAndSetFn(compareFn interface{}) error {
 		return errors.New("expected exactly two parameters, only one gotten")
 	}
 
+	if to.In(0).Kind() != reflect.Ptr || to.In(1).Kind() != reflect.Ptr {
+		return ErrMustBePointerReceiver
+	}
+
 	if to.In(0) != to.In(1) {
 		return errors.New("both input parameters of the function must be of the same type")
 	}
 
+	if elem := to.In(0).Elem(); elem.Kind() == reflect.Interface {
+		if elem.NumMethod() == 0 {
+			return ErrEmptyInterface
+		}
+		if h.strictTypes && !token.IsExported(elem.Name()) {
+			return errors.New("elems must not be pointers to unnamed or unexported interfaces")
+		}
+	}
+
 	h.cmpFn = reflect.ValueOf(compareFn)
 
 	h.dataType = to.In(0)
-	if h.dataType.Kind() != reflect.Ptr {
-		return errors.New("elems must have a pointer receiver")
-	}
 	if h.dataType.Implements(reflect.TypeOf((*Indexer)(nil)).Elem()) {
 		h.indexer = true
 	}
@@ -89,22 +216,166 @@ func (h *Heap) checkAndSetFn(compareFn interface{}) error {
 	return nil
 }
 
-func (h Heap) Less(i, j int) bool {
-	return h.cmpFn.Call([]reflect.Value{h.objects[i], h.objects[j]})[0].Interface().(bool)
+// derive returns an empty heap ordered and bounded like h. Index tracking and
+// hooks are not carried over, so elements shared with h keep their index in h.
+func (h *Heap) derive() *Heap {
+	d := &Heap{
+		objects:  make([]reflect.Value, 0),
+		cmpFn:    h.cmpFn,
+		dataType: h.dataType,
+		noLookup: h.noLookup,
+
+		immutableCmp:   h.immutableCmp,
+		compareTimeout: h.compareTimeout,
+		seq:            h.seq,
+
+		maxSize: h.maxSize,
+		onEvict: h.onEvict,
+	}
+	if !h.noLookup {
+		d.lookup = make(map[reflect.Value]int)
+	}
+	if nil != h.seqs {
+		d.seqs = make(map[reflect.Value]uint64)
+	}
+	d.resetTimestamps()
+	if nil != h.rng {
+		// a generator of its own keeps the draws of h reproducible and the
+		// derived heap usable from another goroutine
+		d.seed = h.seed + 1
+		d.rng = rand.New(rand.NewSource(d.seed))
+	}
+	if nil != h.ties {
+		d.ties = make(map[reflect.Value]uint64, len(h.ties))
+		for k, v := range h.ties {
+			d.ties[k] = v
+		}
+	}
+	return d
 }
 
-func (h Heap) Swap(i, j int) {
+// clone returns a derived heap holding the elements of h.
+func (h *Heap) clone() *Heap {
+	c := h.derive()
+	c.objects = make([]reflect.Value, len(h.objects))
+	copy(c.objects, h.objects)
+	c.length.Store(int64(len(c.objects)))
+	if !h.noLookup {
+		c.lookup = make(map[reflect.Value]int, len(h.lookup))
+	}
+	for k, v := range h.lookup {
+		c.lookup[k] = v
+	}
+	for k, v := range h.seqs {
+		c.seqs[k] = v
+	}
+	c.pinned = h.pinned
+	c.syncStats()
+	return c
+}
+
+// Copy returns an independent heap holding field level copies of the
+// elements. Pointers inside the elements are shared with the original, except
+// for embedded *IndexMixin values which are renewed so that index tracking of
+// both heaps stays independent. The size bound and its eviction callback are
+// kept, logger, event bus and metrics are not carried over.
+func (h *Heap) Copy() *Heap {
+	c := h.clone()
+	c.indexer = h.indexer
+	for index, obj := range c.objects {
+		if obj.IsNil() {
+			continue
+		}
+		dup := reflect.New(h.dataType.Elem())
+		dup.Elem().Set(obj.Elem())
+		renewIndexMixins(dup.Elem())
+		c.objects[index] = dup
+		if obj == h.pinned {
+			c.pinned = dup
+		}
+		if nil != c.seqs {
+			c.seqs[dup] = h.seqs[obj]
+		}
+	}
+	c.reindex()
+	return c
+}
+
+func renewIndexMixins(v reflect.Value) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	mixinType := reflect.TypeOf((*IndexMixin)(nil))
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Type() == mixinType && field.CanSet() && !field.IsNil() {
+			field.Set(reflect.ValueOf(&IndexMixin{}))
+		}
+	}
+}
+
+func (h *Heap) ReplaceComparator(newCmpFn interface{}) error {
+	tmp := &Heap{strictTypes: h.strictTypes}
+	if err := tmp.checkAndSetFn(newCmpFn); nil != err {
+		return err
+	}
+	if tmp.dataType != h.dataType {
+		return errors.New("new comparator must operate on the same type as the heap")
+	}
+	h.cmpFn = tmp.cmpFn
+	h.FlushComparatorCache()
+	coheap.Init(h)
+	return nil
+}
+
+func (h *Heap) Less(i, j int) bool {
+	h.verifyLess(i, j)
+	return h.compare(h.objects[i], h.objects[j])
+}
+
+func (h *Heap) compare(a, b reflect.Value) bool {
+	if h.pinned.IsValid() && (a == h.pinned || b == h.pinned) {
+		return a == h.pinned && b != h.pinned
+	}
+	ret := h.call(a, b)
+	if h.immutableCmp && ret != h.invoke(a, b) {
+		panic(ErrNonDeterministicComparator)
+	}
+	if nil != h.rng && !ret && !h.call(b, a) {
+		return h.tieRank(a) < h.tieRank(b)
+	}
+	return ret
+}
+
+// tieRank returns the random rank used to order a against equal elements.
+func (h *Heap) tieRank(a reflect.Value) uint64 {
+	rank, ok := h.ties[a]
+	if !ok {
+		rank = h.rng.Uint64()
+		h.ties[a] = rank
+	}
+	return rank
+}
+
+func (h *Heap) Swap(i, j int) {
 	if h.indexer {
 		h.objects[i].Interface().(Indexer).SetIndex(j)
 		h.objects[j].Interface().(Indexer).SetIndex(i)
 	}
-	h.lookup[h.objects[i]] = j
-	h.lookup[h.objects[j]] = i
+	if !h.noLookup {
+		h.lookup[h.objects[i]] = j
+		h.lookup[h.objects[j]] = i
+	}
 	h.objects[i], h.objects[j] = h.objects[j], h.objects[i]
+	if nil != h.logger {
+		h.trace("SWAP", i, j)
+	}
 }
 
-func (h Heap) Len() int {
-	return len(h.objects)
+// Len does not need to synchronize with writers as the length is kept in an
+// atomic counter.
+func (h *Heap) Len() int {
+	return int(h.length.Load())
 }
 
 func (h *Heap) Push(i interface{}) {
@@ -112,67 +383,1135 @@ func (h *Heap) Push(i interface{}) {
 		panic("tried to put invalid type")
 	}
 	val := reflect.ValueOf(i)
-	h.lookup[val] = len(h.objects)
+	if h.indexer {
+		i.(Indexer).SetIndex(len(h.objects))
+	}
+	if !h.noLookup {
+		h.lookup[val] = len(h.objects)
+	}
 	h.objects = append(h.objects, val)
+	h.length.Add(1)
+	h.pushCount.Add(1)
+	h.syncStats()
+	h.setSeq(val)
+}
+
+// setSeq assigns the next insertion sequence number to v if insertion
+// tracking is enabled.
+func (h *Heap) setSeq(v reflect.Value) {
+	if nil == h.seqs {
+		return
+	}
+	h.seq++
+	h.seqs[v] = h.seq
 }
 
 func (h *Heap) Pop() interface{} {
 	length := len(h.objects)
-	ret := h.objects[length - 1].Interface()
+	ret := h.objects[length - 1]
+	delete(h.lookup, ret)
+	delete(h.ties, ret)
+	delete(h.seqs, ret)
+	h.forget(ret)
+	if ret == h.pinned {
+		h.pinned = reflect.Value{}
+	}
 	h.objects = h.objects[:length-1]
-	return ret
+	h.length.Add(-1)
+	h.syncStats()
+	return ret.Interface()
+}
+
+// reindex rebuilds the lookup map, element indices and length after the
+// backing slice was replaced.
+func (h *Heap) reindex() {
+	h.length.Store(int64(len(h.objects)))
+	defer h.syncStats()
+	if !h.noLookup {
+		h.lookup = make(map[reflect.Value]int, len(h.objects))
+	}
+	for index, obj := range h.objects {
+		if !h.noLookup {
+			h.lookup[obj] = index
+		}
+		if h.indexer {
+			obj.Interface().(Indexer).SetIndex(index)
+		}
+	}
+	if nil != h.ties {
+		ties := make(map[reflect.Value]uint64, len(h.objects))
+		for _, obj := range h.objects {
+			if rank, ok := h.ties[obj]; ok {
+				ties[obj] = rank
+			}
+		}
+		h.ties = ties
+	}
+	if nil != h.cache || h.pinned.IsValid() {
+		present := h.members()
+		if nil != h.cache {
+			h.cache.retain(func(v reflect.Value) bool {
+				_, ok := present[v]
+				return ok
+			})
+		}
+		if _, ok := present[h.pinned]; !ok {
+			h.pinned = reflect.Value{}
+		}
+	}
+	if nil != h.seqs {
+		seqs := make(map[reflect.Value]uint64, len(h.objects))
+		for _, obj := range h.objects {
+			seq, ok := h.seqs[obj]
+			if !ok {
+				h.seq++
+				seq = h.seq
+			}
+			seqs[obj] = seq
+		}
+		h.seqs = seqs
+	}
 }
 
 func (h *Heap) Put(i interface{}) {
 	coheap.Push(h, i)
+	h.evict()
+	h.afterPut(i)
+}
+
+// evict shrinks a size bounded heap back to maxSize by dropping the elements
+// with the lowest priority. The heap order must hold when it is called.
+func (h *Heap) evict() {
+	excess := len(h.objects) - h.maxSize
+	if h.maxSize <= 0 || excess <= 0 {
+		return
+	}
+	var evicted []interface{}
+	if excess == 1 {
+		evicted = append(evicted, coheap.Remove(h, h.worstIndex()))
+	} else {
+		// a sorted slice is a valid heap, so keep its head as is
+		sort.Slice(h.objects, func(i, j int) bool {
+			return h.compare(h.objects[i], h.objects[j])
+		})
+		for _, obj := range h.objects[h.maxSize:] {
+			evicted = append(evicted, obj.Interface())
+		}
+		clear(h.objects[h.maxSize:])
+		h.objects = h.objects[:h.maxSize]
+		h.reindex()
+	}
+	if nil != h.onEvict {
+		for _, item := range evicted {
+			h.onEvict(item)
+		}
+	}
+}
+
+func (h *Heap) afterPut(i interface{}) {
+	h.lastPush.Store(time.Now().UnixNano())
+	if nil != h.logger {
+		h.trace("PUT", i)
+	}
+	h.publish("heap.push", i)
+	if nil != h.metrics {
+		h.metrics.ObservePush()
+		h.metrics.ObserveLen(len(h.objects))
+	}
+}
+
+func (h *Heap) pop() interface{} {
+	return h.popAt(0)
+}
+
+// popAt removes the element at index and runs the same hooks as pop.
+func (h *Heap) popAt(index int) interface{} {
+	ret := coheap.Remove(h, index)
+	h.afterPop(ret)
+	return ret
+}
+
+func (h *Heap) afterPop(ret interface{}) {
+	h.popCount.Add(1)
+	h.lastPop.Store(time.Now().UnixNano())
+	if nil != h.logger {
+		h.trace("GET", ret)
+	}
+	h.publish("heap.pop", ret)
+	if nil != h.metrics {
+		h.metrics.ObservePop()
+		h.metrics.ObserveLen(len(h.objects))
+	}
 }
 
 func (h *Heap) Get(i interface{}) {
 	if reflect.TypeOf(i) != h.dataType {
 		panic("bad target type")
 	}
-	ret := coheap.Pop(h)
+	ret := h.pop()
 	reflect.ValueOf(i).Elem().Set(reflect.ValueOf(ret).Elem())
 }
 
-func (h *Heap) Peek(i interface{}) {
+// Peek copies the top element into i. It returns false and leaves i untouched
+// if the heap is empty.
+func (h *Heap) Peek(i interface{}) bool {
 	if reflect.TypeOf(i) != h.dataType {
 		panic("bad target type")
 	}
+	if len(h.objects) == 0 {
+		return false
+	}
 	ret := h.objects[0]
-	reflect.ValueOf(i).Elem().Set(reflect.ValueOf(ret).Elem())
+	reflect.ValueOf(i).Elem().Set(ret.Elem())
+	return true
 }
 
+// DeleteElem removes i and reports whether it was part of the heap. For user
+// provided input prefer TryDeleteElem, which reports type mismatches
+// explicitly.
 func (h *Heap) DeleteElem(i interface{}) bool {
 	v := reflect.ValueOf(i)
-	index, ok := h.lookup[v]
+	index, ok := h.find(v)
 	if !ok {
+		if nil != h.logger {
+			h.trace("DELETE", i, false)
+		}
+		h.observeDelete(false)
 		return false
 	}
 	coheap.Remove(h, index)
-	return false
+	h.deleteCount.Add(1)
+	if nil != h.logger {
+		h.trace("DELETE", i, true)
+	}
+	h.publish("heap.delete", i)
+	h.observeDelete(true)
+	return true
+}
+
+// find returns the position of v. Finding an element by identity needs the
+// lookup map, so it panics with ErrLookupDisabled on heaps built with
+// WithoutLookup instead of reporting every element as missing.
+func (h *Heap) find(v reflect.Value) (int, bool) {
+	if h.noLookup {
+		panic(ErrLookupDisabled)
+	}
+	index, ok := h.lookup[v]
+	return index, ok
 }
 
-type IntElem struct {
-	data int
-	*IndexMixin
+func (h *Heap) observeDelete(found bool) {
+	if nil != h.metrics {
+		h.metrics.ObserveDelete(found)
+		h.metrics.ObserveLen(len(h.objects))
+	}
 }
 
-func NewElem(data int) *IntElem {
-	return &IntElem{
-		data: data,
-		IndexMixin: &IndexMixin{},
+// Reschedule applies mutate to item in place and moves it to its new
+// position. mutate must not call methods of the heap.
+func (h *Heap) Reschedule(item interface{}, mutate func(interface{})) bool {
+	index, ok := h.find(reflect.ValueOf(item))
+	if !ok {
+		return false
 	}
+	mutate(h.objects[index].Interface())
+	h.forget(h.objects[index])
+	coheap.Fix(h, index)
+	return true
 }
 
-func NewMaxHeap() *Heap {
-	return MustHeap(func(i *IntElem, j *IntElem) bool {
-		return i.data > j.data
+func (h *Heap) TryDeleteElem(i interface{}) error {
+	if h.noLookup {
+		return ErrLookupDisabled
+	}
+	if reflect.TypeOf(i) != h.dataType {
+		return ErrTypeMismatch
+	}
+	if !h.DeleteElem(i) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (h *Heap) Update(i interface{}) bool {
+	index, ok := h.find(reflect.ValueOf(i))
+	if !ok {
+		return false
+	}
+	h.forget(h.objects[index])
+	coheap.Fix(h, index)
+	return true
+}
+
+// TryUpdate is Update reporting the reason of a failure: ErrLookupDisabled,
+// ErrTypeMismatch or ErrNotFound.
+func (h *Heap) TryUpdate(i interface{}) error {
+	if h.noLookup {
+		return ErrLookupDisabled
+	}
+	if reflect.TypeOf(i) != h.dataType {
+		return ErrTypeMismatch
+	}
+	if !h.Update(i) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Replace puts new at the position of old and restores the heap. It returns
+// false if old is not part of the heap or new already is.
+func (h *Heap) Replace(old, new interface{}) bool {
+	if reflect.TypeOf(old) != h.dataType || reflect.TypeOf(new) != h.dataType {
+		return false
+	}
+	oldVal, newVal := reflect.ValueOf(old), reflect.ValueOf(new)
+	index, ok := h.find(oldVal)
+	if !ok {
+		return false
+	}
+	if _, ok := h.lookup[newVal]; ok {
+		return false
+	}
+	h.objects[index] = newVal
+	delete(h.lookup, oldVal)
+	h.lookup[newVal] = index
+	delete(h.ties, oldVal)
+	if nil != h.seqs {
+		h.seqs[newVal] = h.seqs[oldVal]
+		delete(h.seqs, oldVal)
+	}
+	h.forget(oldVal)
+	if oldVal == h.pinned {
+		h.pinned = reflect.Value{}
+	}
+	if h.indexer {
+		new.(Indexer).SetIndex(index)
+	}
+	coheap.Fix(h, index)
+	return true
+}
+
+// EvictLargerThan removes every element that threshold takes precedence over
+// and returns how many were removed.
+func (h *Heap) EvictLargerThan(threshold interface{}) int {
+	if reflect.TypeOf(threshold) != h.dataType {
+		return 0
+	}
+	t := reflect.ValueOf(threshold)
+	return h.Compact(func(i interface{}) bool {
+		return h.compare(t, reflect.ValueOf(i))
 	})
 }
 
-func NewMinHeap() *Heap {
-	return MustHeap(func(i *IntElem, j *IntElem) bool {
-		return i.data < j.data
+// DisableIndexTracking stops calling SetIndex on Indexer elements, which saves
+// work on every swap. GetIndex of the elements is stale until tracking is
+// enabled again.
+func (h *Heap) DisableIndexTracking() {
+	h.indexer = false
+	h.syncStats()
+}
+
+// EnableIndexTracking resumes index tracking and repairs the indices of all
+// elements. It has no effect if the elements do not implement Indexer.
+func (h *Heap) EnableIndexTracking() {
+	if h.indexer || !h.dataType.Implements(reflect.TypeOf((*Indexer)(nil)).Elem()) {
+		return
+	}
+	h.indexer = true
+	h.reindex()
+}
+
+func (h *Heap) IsIndexTrackingEnabled() bool {
+	return h.indexer
+}
+
+// BatchFix restores the heap after the priorities of items changed. It fixes
+// the items one by one, or re-heapifies everything at once if more than a
+// quarter of the heap was touched. It returns the number of items found.
+func (h *Heap) BatchFix(items ...interface{}) int {
+	found := make([]reflect.Value, 0, len(items))
+	for _, item := range items {
+		v := reflect.ValueOf(item)
+		if _, ok := h.find(v); ok {
+			found = append(found, v)
+			h.forget(v)
+		}
+	}
+	if len(found) > len(h.objects)/4 {
+		coheap.Init(h)
+		return len(found)
+	}
+	for _, v := range found {
+		coheap.Fix(h, h.lookup[v])
+	}
+	return len(found)
+}
+
+// SwapElements exchanges the positions of a and b without restoring the heap
+// property afterwards. It deliberately breaks the heap invariant and is meant
+// for tests and visualizations only; container/heap.Init repairs the heap.
+func (h *Heap) SwapElements(a, b interface{}) error {
+	if h.noLookup {
+		return ErrLookupDisabled
+	}
+	indexA, ok := h.lookup[reflect.ValueOf(a)]
+	if !ok {
+		return ErrNotFound
+	}
+	indexB, ok := h.lookup[reflect.ValueOf(b)]
+	if !ok {
+		return ErrNotFound
+	}
+	if indexA >= len(h.objects) || indexB >= len(h.objects) {
+		return errors.New("element index out of range")
+	}
+	h.Swap(indexA, indexB)
+	return nil
+}
+
+func (h *Heap) CountIf(pred func(interface{}) bool) int {
+	count := 0
+	for _, obj := range h.objects {
+		if pred(obj.Interface()) {
+			count++
+		}
+	}
+	return count
+}
+
+func (h *Heap) ExactlyOne(pred func(interface{}) bool) bool {
+	found := false
+	for _, obj := range h.objects {
+		if pred(obj.Interface()) {
+			if found {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+func (h *Heap) None(pred func(interface{}) bool) bool {
+	for _, obj := range h.objects {
+		if pred(obj.Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reset removes all elements but keeps the allocated capacity.
+func (h *Heap) Reset() {
+	clear(h.objects)
+	h.objects = h.objects[:0]
+	h.length.Store(0)
+	clear(h.lookup)
+	h.syncStats()
+	clear(h.ties)
+	clear(h.seqs)
+	h.FlushComparatorCache()
+	h.pinned = reflect.Value{}
+}
+
+// Compact drops every element for which isDeleted returns true and
+// re-establishes the heap. It returns the number of dropped elements.
+func (h *Heap) Compact(isDeleted func(interface{}) bool) int {
+	kept := h.objects[:0]
+	for _, obj := range h.objects {
+		if !isDeleted(obj.Interface()) {
+			kept = append(kept, obj)
+		}
+	}
+	removed := len(h.objects) - len(kept)
+	for i := len(kept); i < len(h.objects); i++ {
+		h.objects[i] = reflect.Value{}
+	}
+	h.objects = kept
+	h.reindex()
+	coheap.Init(h)
+	return removed
+}
+
+// TakeWhile pops and returns top elements as long as pred holds for them.
+func (h *Heap) TakeWhile(pred func(interface{}) bool) []interface{} {
+	var ret []interface{}
+	for len(h.objects) > 0 && pred(h.objects[0].Interface()) {
+		ret = append(ret, h.pop())
+	}
+	return ret
+}
+
+// DropWhile pops top elements as long as pred holds for them and returns how
+// many were dropped.
+func (h *Heap) DropWhile(pred func(interface{}) bool) int {
+	dropped := 0
+	for len(h.objects) > 0 && pred(h.objects[0].Interface()) {
+		h.pop()
+		dropped++
+	}
+	return dropped
+}
+
+// IterSorted calls fn for every element in priority order, starting at rank
+// 0, until fn returns false. The heap itself is not modified.
+func (h *Heap) IterSorted(fn func(item interface{}, rank int) bool) {
+	c := h.clone()
+	for rank := 0; c.Len() > 0; rank++ {
+		if !fn(coheap.Pop(c), rank) {
+			return
+		}
+	}
+}
+
+// PopToSlice pops elements into the free capacity of the slice dst points to
+// and returns how many were appended. dst must be a *[]T with T being the
+// element type of the heap, otherwise nothing happens.
+func (h *Heap) PopToSlice(dst interface{}) int {
+	ptr := reflect.ValueOf(dst)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Slice || ptr.Elem().Type().Elem() != h.dataType {
+		return 0
+	}
+	slice := ptr.Elem()
+	count := 0
+	for slice.Len() < slice.Cap() && len(h.objects) > 0 {
+		slice = reflect.Append(slice, reflect.ValueOf(h.pop()))
+		count++
+	}
+	ptr.Elem().Set(slice)
+	return count
+}
+
+// PushFromSlice pushes every element of the slice src and re-heapifies once.
+// It fails without pushing anything if the slice element type does not match.
+func (h *Heap) PushFromSlice(src interface{}) (int, error) {
+	slice := reflect.ValueOf(src)
+	if slice.Kind() != reflect.Slice || slice.Type().Elem() != h.dataType {
+		return 0, ErrTypeMismatch
+	}
+	for index := 0; index < slice.Len(); index++ {
+		h.Push(slice.Index(index).Interface())
+	}
+	coheap.Init(h)
+	h.evict()
+	for index := 0; index < slice.Len(); index++ {
+		h.afterPut(slice.Index(index).Interface())
+	}
+	return slice.Len(), nil
+}
+
+// PeekMany copies up to n elements in backing slice order into dst without
+// allocating and returns how many were written.
+func (h *Heap) PeekMany(n int, dst []interface{}) int {
+	n = min(n, len(h.objects), len(dst))
+	for index := 0; index < n; index++ {
+		dst[index] = h.objects[index].Interface()
+	}
+	return max(n, 0)
+}
+
+// GroupBy distributes the elements into one heap per key. The sub heaps share
+// the elements with h but do not track their indices; h is not modified.
+func (h *Heap) GroupBy(key func(interface{}) interface{}) map[interface{}]*Heap {
+	groups := make(map[interface{}]*Heap)
+	for _, obj := range h.objects {
+		k := key(obj.Interface())
+		group, ok := groups[k]
+		if !ok {
+			group = h.derive()
+			groups[k] = group
+		}
+		group.Push(obj.Interface())
+	}
+	for _, group := range groups {
+		coheap.Init(group)
+	}
+	return groups
+}
+
+// Map builds a new heap ordered by newCompareFn from the transformed elements
+// of h. h is not modified.
+func (h *Heap) Map(transform func(interface{}) interface{}, newCompareFn interface{}) (*Heap, error) {
+	m, err := NewHeap(newCompareFn)
+	if nil != err {
+		return nil, err
+	}
+	for _, obj := range h.objects {
+		item := transform(obj.Interface())
+		if reflect.TypeOf(item) != m.dataType {
+			return nil, ErrTypeMismatch
+		}
+		m.Push(item)
+	}
+	coheap.Init(m)
+	return m, nil
+}
+
+// Peek2 returns the two top elements without removing them. The second one
+// is the better of the two children of the root.
+func (h *Heap) Peek2() (first, second interface{}, ok bool) {
+	if len(h.objects) < 2 {
+		return nil, nil, false
+	}
+	next := 1
+	if len(h.objects) > 2 && h.Less(2, 1) {
+		next = 2
+	}
+	return h.objects[0].Interface(), h.objects[next].Interface(), true
+}
+
+// TeeOff passes a clone of h to fn and returns h unchanged. The clone can be
+// modified freely; only the elements themselves are shared, so mutating their
+// fields is visible in h as well. Use Copy for fully independent elements.
+func (h *Heap) TeeOff(fn func(*Heap)) *Heap {
+	fn(h.clone())
+	return h
+}
+
+// WarmUp allocates room for n elements so that the first n pushes do not
+// allocate. It panics if the heap is not empty.
+func (h *Heap) WarmUp(n int) {
+	if len(h.objects) > 0 {
+		panic("WarmUp called on non-empty heap")
+	}
+	h.objects = make([]reflect.Value, 0, n)
+	if !h.noLookup {
+		h.lookup = make(map[reflect.Value]int, n)
+	}
+	if nil != h.seqs {
+		h.seqs = make(map[reflect.Value]uint64, n)
+	}
+	h.syncStats()
+}
+
+// PeekLast returns the element with the lowest priority. It scans the leaves
+// in the second half of the backing slice and runs in O(n).
+func (h *Heap) PeekLast() (interface{}, bool) {
+	if len(h.objects) == 0 {
+		return nil, false
+	}
+	return h.objects[h.worstIndex()].Interface(), true
+}
+
+func (h *Heap) worstIndex() int {
+	worst := len(h.objects) / 2
+	for index := worst + 1; index < len(h.objects); index++ {
+		if h.Less(worst, index) {
+			worst = index
+		}
+	}
+	return worst
+}
+
+// ForEachParallel calls fn for every element from up to workers goroutines and
+// waits for all of them. fn must be safe for concurrent use and must not
+// modify the heap.
+func (h *Heap) ForEachParallel(workers int, fn func(interface{})) {
+	workers = max(1, min(workers, len(h.objects)))
+	chunk := (len(h.objects) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(h.objects); start += chunk {
+		wg.Add(1)
+		go func(objects []reflect.Value) {
+			defer wg.Done()
+			for _, obj := range objects {
+				fn(obj.Interface())
+			}
+		}(h.objects[start:min(start+chunk, len(h.objects))])
+	}
+	wg.Wait()
+}
+
+type HeapEntry struct {
+	Index  int
+	Value  interface{}
+	Depth  int
+	IsLeaf bool
+}
+
+// Enumerate describes every element together with its position in the binary
+// tree the backing slice represents.
+func (h *Heap) Enumerate() []HeapEntry {
+	entries := make([]HeapEntry, len(h.objects))
+	for index, obj := range h.objects {
+		entries[index] = HeapEntry{
+			Index:  index,
+			Value:  obj.Interface(),
+			Depth:  bits.Len(uint(index+1)) - 1,
+			IsLeaf: 2*index+1 >= len(h.objects),
+		}
+	}
+	return entries
+}
+
+// SwapTop replaces the top element by newTop and sifts it down, which costs a
+// single O(log n) pass. It returns the previous top element. Counters and
+// hooks see a pop followed by a push.
+func (h *Heap) SwapTop(newTop interface{}) (oldTop interface{}, ok bool) {
+	if reflect.TypeOf(newTop) != h.dataType {
+		panic("tried to put invalid type")
+	}
+	if len(h.objects) == 0 {
+		return nil, false
+	}
+	old, val := h.objects[0], reflect.ValueOf(newTop)
+	delete(h.lookup, old)
+	delete(h.ties, old)
+	delete(h.seqs, old)
+	h.forget(old)
+	if old == h.pinned {
+		h.pinned = reflect.Value{}
+	}
+	h.objects[0] = val
+	h.setSeq(val)
+	if !h.noLookup {
+		h.lookup[val] = 0
+	}
+	if h.indexer {
+		newTop.(Indexer).SetIndex(0)
+	}
+	coheap.Fix(h, 0)
+	h.pushCount.Add(1)
+	h.afterPop(old.Interface())
+	h.afterPut(newTop)
+	return old.Interface(), true
+}
+
+// Prioritize returns items in the order the heap would pop them. Only the
+// comparator of h is used, its contents are left alone.
+func (h *Heap) Prioritize(items []interface{}) ([]interface{}, error) {
+	tmp := h.derive()
+	for _, item := range items {
+		if reflect.TypeOf(item) != h.dataType {
+			return nil, ErrTypeMismatch
+		}
+		tmp.Push(item)
+	}
+	coheap.Init(tmp)
+	ret := make([]interface{}, 0, len(items))
+	for tmp.Len() > 0 {
+		ret = append(ret, coheap.Pop(tmp))
+	}
+	return ret, nil
+}
+
+// Invert returns a heap holding the elements of h in reverse order. The
+// comparator is reversed by swapping its arguments rather than negating its
+// result, which keeps equal elements equal. Like all derived heaps it shares
+// the elements with h without tracking their indices.
+func (h *Heap) Invert() *Heap {
+	inv := h.clone()
+	inv.pinned = reflect.Value{}
+	cmpFn := h.cmpFn
+	inv.cmpFn = reflect.MakeFunc(cmpFn.Type(), func(args []reflect.Value) []reflect.Value {
+		return cmpFn.Call([]reflect.Value{args[1], args[0]})
 	})
+	coheap.Init(inv)
+	return inv
+}
+
+// MergeAll moves the elements of all others into h and leaves them empty.
+// Nothing is merged if any of the heaps is incompatible.
+func (h *Heap) MergeAll(others ...*Heap) error {
+	var errs []error
+	for index, other := range others {
+		if other == h {
+			errs = append(errs, fmt.Errorf("heap %d: cannot merge a heap into itself", index))
+		} else if other.dataType != h.dataType {
+			errs = append(errs, fmt.Errorf("heap %d: %w", index, ErrTypeMismatch))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	var added []interface{}
+	for _, other := range others {
+		for _, obj := range other.objects {
+			added = append(added, obj.Interface())
+		}
+		h.objects = append(h.objects, other.objects...)
+		other.Reset()
+	}
+	h.reindex()
+	h.pushCount.Add(uint64(len(added)))
+	coheap.Init(h)
+	h.evict()
+	for _, item := range added {
+		h.afterPut(item)
+	}
+	return nil
+}
+
+// Index returns a snapshot of the element to position mapping.
+func (h *Heap) Index() map[interface{}]int {
+	index := make(map[interface{}]int, len(h.objects))
+	for obj, pos := range h.members() {
+		index[obj.Interface()] = pos
+	}
+	return index
+}
+
+func (h *Heap) IndexOf(item interface{}) (int, bool) {
+	return h.find(reflect.ValueOf(item))
+}
+
+// SplitAt divides the heap into one heap holding the n elements with the best
+// priority and another holding the remaining ones. h itself is not modified.
+func (h *Heap) SplitAt(n int) (top *Heap, rest *Heap, err error) {
+	if n < 0 || n > len(h.objects) {
+		return nil, nil, fmt.Errorf("split index %d out of range [0, %d]", n, len(h.objects))
+	}
+	rest = h.clone()
+	top = h.derive()
+	for i := 0; i < n; i++ {
+		coheap.Push(top, coheap.Pop(rest))
+	}
+	return top, rest, nil
+}
+
+// MapInPlace replaces every element with the result of transform and restores
+// the heap order once afterwards. If any result has the wrong type the heap is
+// left untouched and ErrTypeMismatch is returned.
+func (h *Heap) MapInPlace(transform func(interface{}) interface{}) error {
+	objects := make([]reflect.Value, len(h.objects))
+	for index, obj := range h.objects {
+		item := transform(obj.Interface())
+		if reflect.TypeOf(item) != h.dataType {
+			return ErrTypeMismatch
+		}
+		objects[index] = reflect.ValueOf(item)
+	}
+	h.objects = objects
+	h.reindex()
+	h.FlushComparatorCache()
+	coheap.Init(h)
+	return nil
+}
+
+// ToMap returns the elements as a map with every element mapped to itself.
+func (h *Heap) ToMap() map[interface{}]interface{} {
+	m := make(map[interface{}]interface{}, len(h.objects))
+	for _, obj := range h.objects {
+		item := obj.Interface()
+		m[item] = item
+	}
+	return m
+}
+
+// ToSet returns the elements as a set.
+func (h *Heap) ToSet() map[interface{}]struct{} {
+	set := make(map[interface{}]struct{}, len(h.objects))
+	for _, obj := range h.objects {
+		set[obj.Interface()] = struct{}{}
+	}
+	return set
+}
+
+// PopAndPush removes the top element and puts newItem in a single sift down.
+// Unlike SwapTop it reports a wrong type or an empty heap as an error.
+func (h *Heap) PopAndPush(newItem interface{}) (oldTop interface{}, err error) {
+	if reflect.TypeOf(newItem) != h.dataType {
+		return nil, ErrTypeMismatch
+	}
+	oldTop, ok := h.SwapTop(newItem)
+	if !ok {
+		return nil, ErrEmptyHeap
+	}
+	return oldTop, nil
+}
+
+// resetTimestamps makes a new heap look as if it had just been pushed to and
+// popped from, so that a fresh heap does not appear idle.
+func (h *Heap) resetTimestamps() {
+	now := time.Now().UnixNano()
+	h.lastPush.Store(now)
+	h.lastPop.Store(now)
+}
+
+// ElapsedSinceLastPop returns the time since an element was last popped. If
+// nothing was popped yet, it is measured from the creation of the heap.
+func (h *Heap) ElapsedSinceLastPop() time.Duration {
+	return time.Since(time.Unix(0, h.lastPop.Load()))
+}
+
+// ElapsedSinceLastPush is like ElapsedSinceLastPop for pushed elements.
+func (h *Heap) ElapsedSinceLastPush() time.Duration {
+	return time.Since(time.Unix(0, h.lastPush.Load()))
+}
+
+// Hasher can be implemented by elements to speed up Deduplicate. Elements
+// that are equal must have the same hash.
+type Hasher interface {
+	Hash() uint64
+}
+
+// Deduplicate removes all but the highest priority element of every group of
+// elements considered equal by equalFn and returns how many were removed.
+// Without Hasher all pairs are compared, which takes O(n²).
+func (h *Heap) Deduplicate(equalFn func(a, b interface{}) bool) int {
+	hashable := h.dataType.Implements(reflect.TypeOf((*Hasher)(nil)).Elem())
+	var kept []reflect.Value
+	buckets := make(map[uint64][]int)
+	for _, obj := range h.objects {
+		var hash uint64
+		if hashable {
+			hash = obj.Interface().(Hasher).Hash()
+		}
+		found := -1
+		if hashable {
+			for _, index := range buckets[hash] {
+				if equalFn(kept[index].Interface(), obj.Interface()) {
+					found = index
+					break
+				}
+			}
+		} else {
+			for index := range kept {
+				if equalFn(kept[index].Interface(), obj.Interface()) {
+					found = index
+					break
+				}
+			}
+		}
+		switch {
+		case found < 0:
+			if hashable {
+				buckets[hash] = append(buckets[hash], len(kept))
+			}
+			kept = append(kept, obj)
+		case h.compare(obj, kept[found]):
+			kept[found] = obj
+		}
+	}
+	removed := len(h.objects) - len(kept)
+	h.objects = kept
+	h.reindex()
+	coheap.Init(h)
+	return removed
+}
+
+// Sample returns up to n elements chosen uniformly at random using reservoir
+// sampling. If rng is nil the global source is used. The heap is not modified.
+func (h *Heap) Sample(n int, rng *rand.Rand) []interface{} {
+	intn := rand.Intn
+	if nil != rng {
+		intn = rng.Intn
+	}
+	n = max(min(n, len(h.objects)), 0)
+	sample := make([]interface{}, n)
+	for index, obj := range h.objects {
+		if index < n {
+			sample[index] = obj.Interface()
+		} else if pick := intn(index + 1); pick < n {
+			sample[pick] = obj.Interface()
+		}
+	}
+	return sample
+}
+
+// PopOldest removes the top element, preferring among elements of equal
+// priority the one that was inserted first. It requires WithInsertionTracking
+// and panics with ErrInsertionTrackingDisabled otherwise.
+func (h *Heap) PopOldest() (interface{}, bool) {
+	if nil == h.seqs {
+		panic(ErrInsertionTrackingDisabled)
+	}
+	if len(h.objects) == 0 {
+		return nil, false
+	}
+	if h.objects[0] == h.pinned {
+		return h.pop(), true
+	}
+	top, oldest := h.objects[0], 0
+	// children of an element worse than the top cannot tie with the top either
+	pending := []int{0}
+	for len(pending) > 0 {
+		index := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		obj := h.objects[index]
+		if index != 0 && (h.call(top, obj) || h.call(obj, top)) {
+			continue
+		}
+		if h.seqs[obj] < h.seqs[h.objects[oldest]] {
+			oldest = index
+		}
+		for child := 2*index + 1; child <= 2*index+2 && child < len(h.objects); child++ {
+			pending = append(pending, child)
+		}
+	}
+	return h.popAt(oldest), true
+}
+
+// PrioritizeKeys returns the current positions of keys, with found telling
+// whether each key is in the heap. It costs O(1) per key.
+func (h *Heap) PrioritizeKeys(keys []interface{}) (positions []int, found []bool) {
+	positions = make([]int, len(keys))
+	found = make([]bool, len(keys))
+	for index, key := range keys {
+		positions[index], found[index] = h.IndexOf(key)
+	}
+	return positions, found
+}
+
+// MoveToFront pins item to the top regardless of its priority until it
+// leaves the heap or another element is moved to the front. While pinned it
+// takes precedence over every other element, so later puts and updates keep
+// it on top. It returns false if item is not in the heap.
+func (h *Heap) MoveToFront(item interface{}) bool {
+	v := reflect.ValueOf(item)
+	if _, ok := h.find(v); !ok {
+		return false
+	}
+	if h.pinned.IsValid() && h.pinned != v {
+		// the previous pin sits on top, let it sink to its own priority
+		h.pinned = reflect.Value{}
+		coheap.Fix(h, 0)
+	}
+	h.pinned = v
+	coheap.Fix(h, h.lookup[v])
+	return true
+}
+
+// MinElem returns the element the comparator orders first, which is the top,
+// in O(1). For a min heap this is the minimum, for a max heap the maximum.
+func (h *Heap) MinElem() (interface{}, bool) {
+	if len(h.objects) == 0 {
+		return nil, false
+	}
+	return h.objects[0].Interface(), true
+}
+
+// MaxElem returns the element the comparator orders last in O(n), see
+// PeekLast. For a min heap this is the maximum, for a max heap the minimum.
+func (h *Heap) MaxElem() (interface{}, bool) {
+	return h.PeekLast()
+}
+
+// ParallelBulkBuild adds items to the heap, validating and wrapping them in
+// workers goroutines before restoring the heap order once. If any item has the
+// wrong type, the error of the first such item is returned and the heap is
+// left unchanged.
+func (h *Heap) ParallelBulkBuild(items []interface{}, workers int) error {
+	values := make([]reflect.Value, len(items))
+	workers = max(1, min(workers, len(items)))
+	chunk := (len(items) + workers - 1) / workers
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for worker, start := 0, 0; start < len(items); worker, start = worker+1, start+chunk {
+		wg.Add(1)
+		go func(worker, start, end int) {
+			defer wg.Done()
+			for index := start; index < end; index++ {
+				if reflect.TypeOf(items[index]) != h.dataType {
+					errs[worker] = fmt.Errorf("item %d: %w", index, ErrTypeMismatch)
+					return
+				}
+				values[index] = reflect.ValueOf(items[index])
+			}
+		}(worker, start, min(start+chunk, len(items)))
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if nil != err {
+			return err
+		}
+	}
+
+	h.objects = append(h.objects, values...)
+	h.reindex()
+	h.pushCount.Add(uint64(len(items)))
+	coheap.Init(h)
+	h.evict()
+	for _, item := range items {
+		h.afterPut(item)
+	}
+	return nil
+}
+
+// TreeString renders heaps of up to 63 elements, six levels, as an ASCII
+// tree. Larger heaps are summarized by their length and top element.
+func (h *Heap) TreeString() string {
+	if len(h.objects) == 0 {
+		return "Heap(len=0)"
+	}
+	if len(h.objects) > 63 {
+		return fmt.Sprintf("Heap(len=%d, top=%v, ...)", len(h.objects), h.objects[0].Interface())
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v\n", h.objects[0].Interface())
+	h.writeTree(&b, 0, "")
+	return b.String()
+}
+
+func (h *Heap) writeTree(b *strings.Builder, index int, prefix string) {
+	for child := 2*index + 1; child <= 2*index+2 && child < len(h.objects); child++ {
+		connector, indent := "├── ", "│   "
+		if child == 2*index+2 || child+1 == len(h.objects) {
+			connector, indent = "└── ", "    "
+		}
+		fmt.Fprintf(b, "%s%s%v\n", prefix, connector, h.objects[child].Interface())
+		h.writeTree(b, child, prefix+indent)
+	}
+}
+
+// Rotate pops the n top elements and puts back what deprioritize returns for
+// each of them, restoring the heap order once at the end. A nil deprioritize
+// puts the elements back unchanged.
+func (h *Heap) Rotate(n int, deprioritize func(interface{}) interface{}) {
+	n = max(min(n, len(h.objects)), 0)
+	rotated := make([]interface{}, n)
+	for index := range rotated {
+		rotated[index] = h.pop()
+	}
+	for _, item := range rotated {
+		if nil != deprioritize {
+			item = deprioritize(item)
+		}
+		h.Push(item)
+		h.afterPut(item)
+	}
+	coheap.Init(h)
+}
+
+// PeekPriority returns what extract yields for the top element without
+// removing it.
+func (h *Heap) PeekPriority(extract func(interface{}) interface{}) (interface{}, bool) {
+	if len(h.objects) == 0 {
+		return nil, false
+	}
+	return extract(h.objects[0].Interface()), true
+}
+
+// InsertionOrder returns the elements in the order they were put, oldest
+// first. Updated elements keep their place, and an element put by Replace
+// takes over the place of the one it replaced. It requires
+// WithInsertionTracking and panics with ErrInsertionTrackingDisabled
+// otherwise.
+func (h *Heap) InsertionOrder() []interface{} {
+	if nil == h.seqs {
+		panic(ErrInsertionTrackingDisabled)
+	}
+	objects := make([]reflect.Value, len(h.objects))
+	copy(objects, h.objects)
+	sort.Slice(objects, func(i, j int) bool {
+		return h.seqs[objects[i]] < h.seqs[objects[j]]
+	})
+	order := make([]interface{}, len(objects))
+	for index, obj := range objects {
+		order[index] = obj.Interface()
+	}
+	return order
+}
+
+// Rebalance restores the heap order after elements were mutated in place
+// without telling the heap, which costs O(n).
+func (h *Heap) Rebalance() {
+	h.FlushComparatorCache()
+	coheap.Init(h)
+}
+
+// RebalanceOne restores the heap order after item was mutated in place. It is
+// the same as Update and returns false if item is not in the heap.
+func (h *Heap) RebalanceOne(item interface{}) bool {
+	return h.Update(item)
 }
@@ -0,0 +1,1640 @@
+package heap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newIntMaxHeap(t *testing.T, values ...int) *Heap {
+	t.Helper()
+	h := NewMaxHeap()
+	for _, v := range values {
+		h.Put(NewElem(v))
+	}
+	return h
+}
+
+func elemValues(items []interface{}) []int {
+	values := make([]int, len(items))
+	for index, item := range items {
+		values[index] = item.(*IntElem).data
+	}
+	return values
+}
+
+func mustBeHealthy(t *testing.T, h *Heap) {
+	t.Helper()
+	if err := h.HealthCheck(); nil != err {
+		t.Fatal(err)
+	}
+	if issues := h.VerifyLookup(); len(issues) > 0 {
+		t.Fatal(issues)
+	}
+}
+
+func newEvictHeap(t *testing.T, maxSize int) (*Heap, *[]int) {
+	t.Helper()
+	var evicted []int
+	h, err := NewHeapWithEvictCallback(func(a, b *IntElem) bool {
+		return a.data > b.data
+	}, maxSize, func(item interface{}) {
+		evicted = append(evicted, item.(*IntElem).data)
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	return h, &evicted
+}
+
+func TestEvictOnPut(t *testing.T) {
+	h, evicted := newEvictHeap(t, 3)
+	for _, v := range []int{5, 1, 9, 7, 3, 8} {
+		h.Put(NewElem(v))
+	}
+	mustBeHealthy(t, h)
+	if h.Len() != 3 {
+		t.Fatalf("expected 3 elements, got %d", h.Len())
+	}
+	if want := []int{1, 3, 5}; !reflect.DeepEqual(*evicted, want) {
+		t.Fatalf("expected %v to be evicted, got %v", want, *evicted)
+	}
+	if got := drainInts(h); !reflect.DeepEqual(got, []int{9, 8, 7}) {
+		t.Fatalf("unexpected remaining elements %v", got)
+	}
+}
+
+func TestEvictOnBulkOperations(t *testing.T) {
+	h, evicted := newEvictHeap(t, 2)
+	if _, err := h.PushFromSlice([]*IntElem{NewElem(1), NewElem(4), NewElem(2), NewElem(3)}); nil != err {
+		t.Fatal(err)
+	}
+	mustBeHealthy(t, h)
+	sort.Ints(*evicted)
+	if h.Len() != 2 || !reflect.DeepEqual(*evicted, []int{1, 2}) {
+		t.Fatalf("PushFromSlice: len %d, evicted %v", h.Len(), *evicted)
+	}
+
+	if err := h.ParallelBulkBuild([]interface{}{NewElem(9), NewElem(0)}, 2); nil != err {
+		t.Fatal(err)
+	}
+	if h.Len() != 2 {
+		t.Fatalf("ParallelBulkBuild: len %d", h.Len())
+	}
+
+	if err := h.MergeAll(newIntMaxHeap(t, 5, 6, 7)); nil != err {
+		t.Fatal(err)
+	}
+	if h.Len() != 2 {
+		t.Fatalf("MergeAll: len %d", h.Len())
+	}
+
+	if err := h.UnflattenFrom(newIntMaxHeap(t, 1, 2, 3).Flatten()); nil != err {
+		t.Fatal(err)
+	}
+	if h.Len() != 2 {
+		t.Fatalf("UnflattenFrom: len %d", h.Len())
+	}
+
+}
+
+type gobElem struct {
+	N int
+}
+
+func TestEvictOnDeserialize(t *testing.T) {
+	byN := func(a, b *gobElem) bool {
+		return a.N > b.N
+	}
+	source := MustHeap(byN)
+	for i := 0; i < 5; i++ {
+		source.Put(&gobElem{i})
+	}
+	var buf bytes.Buffer
+	if err := source.Serialize(gob.NewEncoder(&buf)); nil != err {
+		t.Fatal(err)
+	}
+
+	evicted := 0
+	h, err := NewHeapWithEvictCallback(byN, 3, func(interface{}) { evicted++ })
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := h.Deserialize(gob.NewDecoder(&buf)); nil != err {
+		t.Fatal(err)
+	}
+	mustBeHealthy(t, h)
+	if h.Len() != 3 || evicted != 2 {
+		t.Fatalf("len %d, evicted %d", h.Len(), evicted)
+	}
+}
+
+func TestEvictOnDerivedHeaps(t *testing.T) {
+	h, evicted := newEvictHeap(t, 2)
+	h.Put(NewElem(5))
+	h.Put(NewElem(1))
+	var tee *Heap
+	h.TeeOff(func(c *Heap) { tee = c })
+	for name, derived := range map[string]*Heap{"Copy": h.Copy(), "TeeOff": tee, "Invert": h.Invert()} {
+		*evicted = nil
+		derived.Put(NewElem(3))
+		derived.Put(NewElem(7))
+		if derived.Len() != 2 || len(*evicted) != 2 {
+			t.Fatalf("%s: expected 2 elements and 2 evictions, got %d and %v", name, derived.Len(), *evicted)
+		}
+		mustBeHealthy(t, derived)
+	}
+	if h.Len() != 2 {
+		t.Fatalf("derived heaps changed the original to %d elements", h.Len())
+	}
+}
+
+func TestEvictWithoutCallback(t *testing.T) {
+	h, err := NewHeapWithEvictCallback(func(a, b *IntElem) bool {
+		return a.data > b.data
+	}, 1, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	h.Put(NewElem(1))
+	h.Put(NewElem(2))
+	if got := drainInts(h); !reflect.DeepEqual(got, []int{2}) {
+		t.Fatalf("expected [2], got %v", got)
+	}
+	if _, err := NewHeapWithEvictCallback(func(a, b *IntElem) bool { return true }, 0, nil); nil == err {
+		t.Fatal("expected an error for maxSize 0")
+	}
+}
+
+type lenMetrics struct {
+	NoopMetrics
+	lens []int
+}
+
+func (m *lenMetrics) ObserveLen(n int) {
+	m.lens = append(m.lens, n)
+}
+
+func TestEvictReportsLenAfterEviction(t *testing.T) {
+	metrics := &lenMetrics{}
+	h, err := NewHeapWithEvictCallback(func(a, b *IntElem) bool {
+		return a.data > b.data
+	}, 2, nil, WithMetrics(metrics))
+	if nil != err {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		h.Put(NewElem(i))
+	}
+	for _, n := range metrics.lens {
+		if n > 2 {
+			t.Fatalf("metrics observed length %d above the bound", n)
+		}
+	}
+}
+
+func newTrackedMaxHeap(t *testing.T) *Heap {
+	t.Helper()
+	h, err := NewHeap(func(a, b *IntElem) bool {
+		return a.data > b.data
+	}, WithInsertionTracking())
+	if nil != err {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestPopOldestFIFO(t *testing.T) {
+	h := newTrackedMaxHeap(t)
+	h.Put(NewElem(1))
+	equal := make([]*IntElem, 5)
+	for i := range equal {
+		equal[i] = NewElem(5)
+		h.Put(equal[i])
+	}
+	h.Put(NewElem(9))
+
+	if top, _ := h.PopOldest(); top.(*IntElem).data != 9 {
+		t.Fatalf("expected 9 first, got %v", top)
+	}
+	for i, want := range equal {
+		got, ok := h.PopOldest()
+		if !ok || got != want {
+			t.Fatalf("pop %d: expected element %d of the equal ones", i, i)
+		}
+		mustBeHealthy(t, h)
+	}
+}
+
+func TestInsertionTrackingDisabled(t *testing.T) {
+	h := newIntMaxHeap(t, 1, 2)
+	if nil != h.seqs {
+		t.Fatal("insertion tracking enabled by default")
+	}
+	defer func() {
+		if r := recover(); r != ErrInsertionTrackingDisabled {
+			t.Fatalf("expected ErrInsertionTrackingDisabled, got %v", r)
+		}
+	}()
+	h.PopOldest()
+}
+
+func TestWarmUpPushesDoNotAllocate(t *testing.T) {
+	const n = 1000
+	for _, h := range []*Heap{NewMaxHeap(), newTrackedMaxHeap(t)} {
+		elems := make([]interface{}, n)
+		for i := range elems {
+			elems[i] = NewElem(n - i)
+		}
+		h.WarmUp(n)
+		index := 0
+		allocs := testing.AllocsPerRun(n-1, func() {
+			h.Push(elems[index])
+			index++
+		})
+		if allocs > 0 {
+			t.Fatalf("push after WarmUp allocated %v times per call", allocs)
+		}
+	}
+}
+
+func TestMoveToFrontPinsElement(t *testing.T) {
+	h := newIntMaxHeap(t, 4, 8, 6)
+	low, high := NewElem(1), NewElem(2)
+	h.Put(low)
+	h.Put(high)
+
+	if !h.MoveToFront(low) {
+		t.Fatal("expected the element to be found")
+	}
+	mustBeHealthy(t, h)
+	h.Put(NewElem(9))
+	high.data = 10
+	h.Update(high)
+	mustBeHealthy(t, h)
+	if top := h.objects[0].Interface(); top != low {
+		t.Fatalf("expected the pinned element on top, got %d", top.(*IntElem).data)
+	}
+
+	// a new pin releases the previous one
+	h.MoveToFront(high)
+	mustBeHealthy(t, h)
+	if got := drainInts(h); !reflect.DeepEqual(got, []int{10, 9, 8, 6, 4, 1}) {
+		t.Fatalf("unexpected order %v", got)
+	}
+
+	h.Put(NewElem(3))
+	h.Put(NewElem(5))
+	mustBeHealthy(t, h)
+	var top IntElem
+	if h.Peek(&top); top.data != 5 {
+		t.Fatalf("expected the pin to be gone after its element left, got %d on top", top.data)
+	}
+	if h.MoveToFront(NewElem(7)) {
+		t.Fatal("expected false for an element outside the heap")
+	}
+}
+
+func TestLenMatchesObjects(t *testing.T) {
+	h := newIntMaxHeap(t)
+	item, other := NewElem(4), NewElem(6)
+	var top IntElem
+	dst := make([]*IntElem, 0, 2)
+	operations := []struct {
+		name string
+		run  func()
+	}{
+		{"Put", func() { h.Put(item) }},
+		{"PushFromSlice", func() { h.PushFromSlice([]*IntElem{NewElem(1), NewElem(9), NewElem(9), NewElem(3)}) }},
+		{"Get", func() { h.Get(&top) }},
+		{"Update", func() { item.data = 7; h.Update(item) }},
+		{"Replace", func() { h.Replace(item, other) }},
+		{"SwapTop", func() { h.SwapTop(NewElem(2)) }},
+		{"PopAndPush", func() { h.PopAndPush(NewElem(8)) }},
+		{"DeleteElem", func() { h.DeleteElem(other) }},
+		{"MergeAll", func() { h.MergeAll(newIntMaxHeap(t, 5, 5, 11)) }},
+		{"Deduplicate", func() {
+			h.Deduplicate(func(a, b interface{}) bool { return a.(*IntElem).data == b.(*IntElem).data })
+		}},
+		{"Compact", func() { h.Compact(func(i interface{}) bool { return i.(*IntElem).data < 3 }) }},
+		{"MapInPlace", func() {
+			h.MapInPlace(func(i interface{}) interface{} { return NewElem(i.(*IntElem).data + 1) })
+		}},
+		{"Rotate", func() { h.Rotate(2, nil) }},
+		{"TakeWhile", func() { h.TakeWhile(func(i interface{}) bool { return i.(*IntElem).data > 9 }) }},
+		{"DropWhile", func() { h.DropWhile(func(i interface{}) bool { return i.(*IntElem).data > 8 }) }},
+		{"PopToSlice", func() { h.PopToSlice(&dst) }},
+		{"UnflattenFrom", func() { h.UnflattenFrom(map[int]interface{}{0: NewElem(3), 1: NewElem(1)}) }},
+		{"Reset", func() { h.Reset() }},
+	}
+	for _, operation := range operations {
+		operation.run()
+		if h.Len() != len(h.objects) {
+			t.Fatalf("after %s: Len returned %d for %d elements", operation.name, h.Len(), len(h.objects))
+		}
+		mustBeHealthy(t, h)
+	}
+}
+
+func TestReplaceComparator(t *testing.T) {
+	h := newIntMaxHeap(t, 5, 1, 9, 3, 7)
+	var top IntElem
+	h.Get(&top)
+	if top.data != 9 {
+		t.Fatalf("expected 9 from the max-heap, got %d", top.data)
+	}
+	if err := h.ReplaceComparator(func(a, b *IntElem) bool {
+		return a.data < b.data
+	}); nil != err {
+		t.Fatal(err)
+	}
+	mustBeHealthy(t, h)
+	h.Put(NewElem(4))
+	if got, want := drainInts(h), []int{1, 3, 4, 5, 7}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v after switching to min order, got %v", want, got)
+	}
+	if nil == h.ReplaceComparator(func(a, b *Int64Elem) bool { return a.data < b.data }) {
+		t.Fatal("expected an error for a comparator of another type")
+	}
+}
+
+func TestSwapElements(t *testing.T) {
+	h := newIntMaxHeap(t, 9, 5, 3)
+	top, bottom := h.objects[0].Interface(), h.objects[2].Interface()
+	if err := h.SwapElements(top, bottom); nil != err {
+		t.Fatal(err)
+	}
+	if h.objects[0].Interface() != bottom || h.objects[2].Interface() != top {
+		t.Fatal("expected the elements to trade places")
+	}
+	if nil == h.HealthCheck() {
+		t.Fatal("expected the swap to break the heap invariant")
+	}
+	if issues := h.VerifyLookup(); len(issues) > 0 {
+		t.Fatal(issues)
+	}
+	if err := h.SwapElements(top, NewElem(1)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPredicates(t *testing.T) {
+	even := func(i interface{}) bool { return i.(*IntElem).data%2 == 0 }
+	for _, test := range []struct {
+		name       string
+		values     []int
+		count      int
+		exactlyOne bool
+		none       bool
+	}{
+		{"empty", nil, 0, false, true},
+		{"single match", []int{2}, 1, true, false},
+		{"single miss", []int{1}, 0, false, true},
+		{"all match", []int{2, 4, 6}, 3, false, false},
+		{"one of many", []int{1, 4, 7}, 1, true, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			h := newIntMaxHeap(t, test.values...)
+			if got := h.CountIf(even); got != test.count {
+				t.Errorf("CountIf: expected %d, got %d", test.count, got)
+			}
+			if got := h.ExactlyOne(even); got != test.exactlyOne {
+				t.Errorf("ExactlyOne: expected %v, got %v", test.exactlyOne, got)
+			}
+			if got := h.None(even); got != test.none {
+				t.Errorf("None: expected %v, got %v", test.none, got)
+			}
+		})
+	}
+}
+
+func TestPredicatesShortCircuit(t *testing.T) {
+	h := newIntMaxHeap(t, 2, 4, 6, 8, 10)
+	calls := 0
+	even := func(i interface{}) bool {
+		calls++
+		return i.(*IntElem).data%2 == 0
+	}
+	h.None(even)
+	if calls != 1 {
+		t.Fatalf("expected None to stop at the first match, got %d calls", calls)
+	}
+	calls = 0
+	h.ExactlyOne(even)
+	if calls != 2 {
+		t.Fatalf("expected ExactlyOne to stop at the second match, got %d calls", calls)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	h := newIntMaxHeap(t)
+	for i := 0; i < 20; i++ {
+		h.Put(NewElem(i * 7 % 20))
+	}
+	removed := h.Compact(func(i interface{}) bool {
+		return i.(*IntElem).data%2 == 0
+	})
+	if removed != 10 || h.Len() != 10 {
+		t.Fatalf("expected 10 of 20 elements removed, removed %d and kept %d", removed, h.Len())
+	}
+	mustBeHealthy(t, h)
+	if got, want := drainInts(h), []int{19, 17, 15, 13, 11, 9, 7, 5, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestPeekEmpty(t *testing.T) {
+	h := NewMinHeap()
+	target := IntElem{data: 42}
+	if h.Peek(&target) {
+		t.Fatal("expected Peek on an empty heap to return false")
+	}
+	if target.data != 42 {
+		t.Fatalf("expected the target to stay untouched, got %d", target.data)
+	}
+	h.Put(NewElem(7))
+	if !h.Peek(&target) || target.data != 7 {
+		t.Fatalf("expected to peek 7, got %d", target.data)
+	}
+}
+
+func TestTakeWhile(t *testing.T) {
+	h := newIntMaxHeap(t, 1, 9, 4, 7, 3, 8)
+	taken := h.TakeWhile(func(i interface{}) bool {
+		return i.(*IntElem).data > 5
+	})
+	if got, want := elemValues(taken), []int{9, 8, 7}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	mustBeHealthy(t, h)
+	if h.Len() != 3 {
+		t.Fatalf("expected 3 elements left, got %d", h.Len())
+	}
+}
+
+func TestDropWhile(t *testing.T) {
+	h := newIntMaxHeap(t, 1, 9, 4, 7, 3, 8)
+	dropped := h.DropWhile(func(i interface{}) bool {
+		return i.(*IntElem).data > 3
+	})
+	if dropped != 4 {
+		t.Fatalf("expected 4 dropped elements, got %d", dropped)
+	}
+	mustBeHealthy(t, h)
+	if got, want := drainInts(h), []int{3, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v left, got %v", want, got)
+	}
+	if dropped := h.DropWhile(func(interface{}) bool { return true }); dropped != 0 {
+		t.Fatalf("expected nothing to drop from an empty heap, got %d", dropped)
+	}
+}
+
+func TestTryDeleteElem(t *testing.T) {
+	item := NewElem(3)
+	h := newIntMaxHeap(t, 5, 1)
+	h.Put(item)
+	if err := h.TryDeleteElem(NewInt64Elem(3)); !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+	if err := h.TryDeleteElem(NewElem(3)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := h.TryDeleteElem(item); nil != err {
+		t.Fatal(err)
+	}
+	mustBeHealthy(t, h)
+	if got := drainInts(h); !reflect.DeepEqual(got, []int{5, 1}) {
+		t.Fatalf("unexpected elements %v", got)
+	}
+}
+
+func TestBatchFix(t *testing.T) {
+	// 2 of 16 fixes one by one, 8 of 16 re-heapifies
+	for _, k := range []int{2, 8} {
+		t.Run(fmt.Sprint(k), func(t *testing.T) {
+			h := newIntMaxHeap(t)
+			items := make([]*IntElem, 16)
+			for index := range items {
+				items[index] = NewElem(index)
+				h.Put(items[index])
+			}
+			touched := make([]interface{}, 0, k+1)
+			for index := 0; index < k; index++ {
+				items[index].data += 100
+				touched = append(touched, items[index])
+			}
+			touched = append(touched, NewElem(50))
+			if fixed := h.BatchFix(touched...); fixed != k {
+				t.Fatalf("expected %d fixed elements, got %d", k, fixed)
+			}
+			mustBeHealthy(t, h)
+			if top := h.objects[0].Interface().(*IntElem).data; top != 100+k-1 {
+				t.Fatalf("expected %d on top, got %d", 100+k-1, top)
+			}
+		})
+	}
+}
+
+func BenchmarkBatchFix(b *testing.B) {
+	const n = 4096
+	for _, k := range []int{n / 64, n / 8, n / 2} {
+		for _, mode := range []string{"BatchFix", "Update"} {
+			b.Run(fmt.Sprintf("%s/k=%d", mode, k), func(b *testing.B) {
+				h := NewMaxHeap()
+				items := make([]interface{}, n)
+				for index := range items {
+					items[index] = NewElem(index)
+					h.Put(items[index])
+				}
+				touched := items[:k]
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					for _, item := range touched {
+						item.(*IntElem).data = (item.(*IntElem).data * 31) % n
+					}
+					if mode == "BatchFix" {
+						h.BatchFix(touched...)
+						continue
+					}
+					for _, item := range touched {
+						h.Update(item)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	old := NewElem(2)
+	h := newIntMaxHeap(t, 8, 6, 4)
+	h.Put(old)
+	replacement := NewElem(10)
+	if !h.Replace(old, replacement) {
+		t.Fatal("expected the replacement to succeed")
+	}
+	mustBeHealthy(t, h)
+	if _, ok := h.IndexOf(old); ok {
+		t.Fatal("expected the old element to be gone")
+	}
+	if index, ok := h.IndexOf(replacement); !ok || index != 0 {
+		t.Fatalf("expected the new element on top, got index %d", index)
+	}
+	if h.Replace(old, NewElem(1)) {
+		t.Fatal("expected false for an old element outside the heap")
+	}
+	if h.Replace(replacement, h.objects[1].Interface()) {
+		t.Fatal("expected false for a new element already in the heap")
+	}
+	if got := drainInts(h); !reflect.DeepEqual(got, []int{10, 8, 6, 4}) {
+		t.Fatalf("unexpected order %v", got)
+	}
+}
+
+func TestIterSorted(t *testing.T) {
+	h := newIntMaxHeap(t, 3, 9, 1, 7, 5)
+	var got []int
+	h.IterSorted(func(item interface{}, rank int) bool {
+		if rank != len(got) {
+			t.Fatalf("expected rank %d, got %d", len(got), rank)
+		}
+		got = append(got, item.(*IntElem).data)
+		return true
+	})
+	if want := []int{9, 7, 5, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	calls := 0
+	h.IterSorted(func(item interface{}, rank int) bool {
+		calls++
+		return rank < 1
+	})
+	if calls != 2 {
+		t.Fatalf("expected the traversal to stop after 2 calls, got %d", calls)
+	}
+	// the clone must neither remove elements nor renumber the shared ones
+	if h.Len() != 5 {
+		t.Fatalf("expected 5 elements, got %d", h.Len())
+	}
+	mustBeHealthy(t, h)
+}
+
+func TestPopToSlice(t *testing.T) {
+	h := newIntMaxHeap(t)
+	for i := 0; i < 10; i++ {
+		h.Put(NewElem(i))
+	}
+	dst := make([]*IntElem, 0, 5)
+	if n := h.PopToSlice(&dst); n != 5 {
+		t.Fatalf("expected 5 popped elements, got %d", n)
+	}
+	got := make([]int, len(dst))
+	for index, item := range dst {
+		got[index] = item.data
+	}
+	if want := []int{9, 8, 7, 6, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if h.Len() != 5 {
+		t.Fatalf("expected 5 elements left, got %d", h.Len())
+	}
+
+	wrong := make([]*Int64Elem, 0, 5)
+	if n := h.PopToSlice(&wrong); n != 0 || len(wrong) != 0 {
+		t.Fatalf("expected a mismatched slice to stay empty, got %d elements", n)
+	}
+	if n := h.PopToSlice(dst); n != 0 {
+		t.Fatalf("expected a slice passed by value to be rejected, got %d elements", n)
+	}
+	if h.Len() != 5 {
+		t.Fatalf("expected rejected calls to keep the heap intact, got %d elements", h.Len())
+	}
+}
+
+func TestPushFromSlice(t *testing.T) {
+	src := make([]*IntElem, 1000)
+	for index := range src {
+		src[index] = NewElem(index * 7919 % 1000)
+	}
+	h := newIntMaxHeap(t)
+	n, err := h.PushFromSlice(src)
+	if nil != err || n != 1000 {
+		t.Fatalf("expected 1000 pushed elements, got %d and %v", n, err)
+	}
+	mustBeHealthy(t, h)
+	got := drainInts(h)
+	for index, v := range got {
+		if v != 999-index {
+			t.Fatalf("expected %d at rank %d, got %d", 999-index, index, v)
+		}
+	}
+	if _, err := h.PushFromSlice([]*Int64Elem{NewInt64Elem(1)}); !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+	if h.Len() != 0 {
+		t.Fatalf("expected a rejected slice to push nothing, got %d elements", h.Len())
+	}
+}
+
+func TestCopy(t *testing.T) {
+	items := []*IntElem{NewElem(4), NewElem(8), NewElem(2)}
+	h := newIntMaxHeap(t)
+	for _, item := range items {
+		h.Put(item)
+	}
+	c := h.Copy()
+	for _, item := range items {
+		item.data += 10
+		h.Update(item)
+	}
+	mustBeHealthy(t, h)
+	mustBeHealthy(t, c)
+	if got := drainInts(c); !reflect.DeepEqual(got, []int{8, 4, 2}) {
+		t.Fatalf("expected the copy to keep the old values, got %v", got)
+	}
+	if got := drainInts(h); !reflect.DeepEqual(got, []int{18, 14, 12}) {
+		t.Fatalf("unexpected original values %v", got)
+	}
+}
+
+func TestPeekMany(t *testing.T) {
+	h := newIntMaxHeap(t, 3, 9, 1, 7, 5)
+	dst := make([]interface{}, 3)
+	if n := h.PeekMany(5, dst); n != 3 {
+		t.Fatalf("expected dst to limit the count to 3, got %d", n)
+	}
+	for index, item := range dst {
+		if h.objects[index].Interface() != item {
+			t.Fatalf("expected backing slice order at index %d", index)
+		}
+	}
+	if n := h.PeekMany(2, make([]interface{}, 8)); n != 2 {
+		t.Fatalf("expected n to limit the count to 2, got %d", n)
+	}
+	if n := h.PeekMany(-1, dst); n != 0 {
+		t.Fatalf("expected 0 for a negative n, got %d", n)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { h.PeekMany(3, dst) }); allocs != 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+	if h.Len() != 5 {
+		t.Fatalf("expected PeekMany to keep the elements, got %d", h.Len())
+	}
+}
+
+func BenchmarkPeekMany(b *testing.B) {
+	h := NewMaxHeap()
+	for i := 0; i < 1000; i++ {
+		h.Put(NewElem(i))
+	}
+	dst := make([]interface{}, 5)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.PeekMany(5, dst)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	h := newIntMaxHeap(t, 4, 7, 1, 8, 3, 6, 2)
+	groups := h.GroupBy(func(i interface{}) interface{} {
+		if i.(*IntElem).data%2 == 0 {
+			return "even"
+		}
+		return "odd"
+	})
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	if got, want := drainInts(groups["even"]), []int{8, 6, 4, 2}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("even: expected %v, got %v", want, got)
+	}
+	if got, want := drainInts(groups["odd"]), []int{7, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("odd: expected %v, got %v", want, got)
+	}
+	if h.Len() != 7 {
+		t.Fatalf("expected GroupBy to leave the heap intact, got %d elements", h.Len())
+	}
+	mustBeHealthy(t, h)
+}
+
+func TestEvictLargerThan(t *testing.T) {
+	h := NewMinHeap()
+	for _, v := range []int{9, 2, 6, 5, 1, 8, 5} {
+		h.Put(NewElem(v))
+	}
+	if evicted := h.EvictLargerThan(NewElem(5)); evicted != 3 {
+		t.Fatalf("expected 3 evicted elements, got %d", evicted)
+	}
+	mustBeHealthy(t, h)
+	if got, want := drainInts(h), []int{1, 2, 5, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if evicted := h.EvictLargerThan(NewInt64Elem(0)); evicted != 0 {
+		t.Fatalf("expected a threshold of another type to evict nothing, got %d", evicted)
+	}
+}
+
+type task struct {
+	name     string
+	deadline time.Time
+}
+
+func TestRescheduleDeadlines(t *testing.T) {
+	h := MustHeap(func(a, b *task) bool {
+		return a.deadline.Before(b.deadline)
+	})
+	start := time.Unix(0, 0)
+	tasks := map[string]*task{}
+	for index, name := range []string{"backup", "report", "cleanup"} {
+		tasks[name] = &task{name, start.Add(time.Duration(index) * time.Hour)}
+		h.Put(tasks[name])
+	}
+	postpone := func(i interface{}) {
+		i.(*task).deadline = i.(*task).deadline.Add(3 * time.Hour)
+	}
+	if !h.Reschedule(tasks["backup"], postpone) {
+		t.Fatal("expected the task to be found")
+	}
+	if h.Reschedule(&task{name: "unknown"}, postpone) {
+		t.Fatal("expected false for a task outside the heap")
+	}
+	mustBeHealthy(t, h)
+	var got []string
+	for h.Len() > 0 {
+		got = append(got, h.pop().(*task).name)
+	}
+	if want := []string{"report", "cleanup", "backup"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestMap(t *testing.T) {
+	h := newIntMaxHeap(t, 3, 1, 2)
+	m, err := h.Map(func(i interface{}) interface{} {
+		return NewInt64Elem(int64(i.(*IntElem).data) * 10)
+	}, func(a, b *Int64Elem) bool {
+		return a.data < b.data
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	mustBeHealthy(t, m)
+	var got []int64
+	for m.Len() > 0 {
+		got = append(got, m.pop().(*Int64Elem).data)
+	}
+	if want := []int64{10, 20, 30}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	if _, err := h.Map(func(i interface{}) interface{} { return i }, func(a, b *Int64Elem) bool {
+		return a.data < b.data
+	}); !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+	if got := drainInts(h); !reflect.DeepEqual(got, []int{3, 2, 1}) {
+		t.Fatalf("expected Map to leave the original intact, got %v", got)
+	}
+}
+
+func TestPeek2(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for round := 0; round < 100; round++ {
+		h := NewMinHeap()
+		values := make([]int, 2+rng.Intn(30))
+		for index := range values {
+			values[index] = rng.Intn(50)
+			h.Put(NewElem(values[index]))
+		}
+		sort.Ints(values)
+		first, second, ok := h.Peek2()
+		if !ok {
+			t.Fatalf("expected ok for %d elements", len(values))
+		}
+		if first.(*IntElem).data != values[0] || second.(*IntElem).data != values[1] {
+			t.Fatalf("expected %d and %d, got %d and %d", values[0], values[1], first.(*IntElem).data, second.(*IntElem).data)
+		}
+		if h.Len() != len(values) {
+			t.Fatal("expected Peek2 to keep the elements")
+		}
+	}
+	if _, _, ok := newIntMaxHeap(t, 1).Peek2(); ok {
+		t.Fatal("expected false for a single element")
+	}
+}
+
+func TestIndexTracking(t *testing.T) {
+	h := newIntMaxHeap(t, 3, 1, 2)
+	if !h.IsIndexTrackingEnabled() {
+		t.Fatal("expected IntElem heaps to track indices")
+	}
+	h.DisableIndexTracking()
+	if h.IsIndexTrackingEnabled() {
+		t.Fatal("expected tracking to be disabled")
+	}
+	for _, v := range []int{9, 0, 5} {
+		h.Put(NewElem(v))
+	}
+	h.pop()
+	h.EnableIndexTracking()
+	if !h.IsIndexTrackingEnabled() {
+		t.Fatal("expected tracking to be enabled again")
+	}
+	// HealthCheck also verifies that every GetIndex is repaired
+	mustBeHealthy(t, h)
+
+	plain := MustHeap(func(a, b *gobElem) bool { return a.N < b.N })
+	plain.EnableIndexTracking()
+	if plain.IsIndexTrackingEnabled() {
+		t.Fatal("expected no tracking for elements without Indexer")
+	}
+}
+
+func BenchmarkIndexTracking(b *testing.B) {
+	for _, enabled := range []bool{true, false} {
+		b.Run(fmt.Sprintf("enabled=%v", enabled), func(b *testing.B) {
+			h := NewMinHeap()
+			if !enabled {
+				h.DisableIndexTracking()
+			}
+			for i := 0; i < 1024; i++ {
+				h.Put(NewElem(i * 7919 % 1024))
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				h.Put(NewElem(i % 1024))
+				h.pop()
+			}
+		})
+	}
+}
+
+func TestTeeOff(t *testing.T) {
+	h := newIntMaxHeap(t, 4, 8, 2, 6)
+	ret := h.TeeOff(func(c *Heap) {
+		c.pop()
+		c.Put(NewElem(100))
+		c.Compact(func(i interface{}) bool { return i.(*IntElem).data < 5 })
+		c.Reset()
+		c.Put(NewElem(1))
+	})
+	if ret != h {
+		t.Fatal("expected TeeOff to return the receiver")
+	}
+	mustBeHealthy(t, h)
+	if got := drainInts(h); !reflect.DeepEqual(got, []int{8, 6, 4, 2}) {
+		t.Fatalf("expected the original to stay untouched, got %v", got)
+	}
+}
+
+func TestWarmUp(t *testing.T) {
+	h := NewMaxHeap()
+	h.WarmUp(64)
+	if h.Len() != 0 || cap(h.objects) != 64 {
+		t.Fatalf("expected an empty heap with capacity 64, got %d elements and capacity %d", h.Len(), cap(h.objects))
+	}
+	if stats := h.Stats(); stats.Cap != 64 {
+		t.Fatalf("expected Stats to report capacity 64, got %d", stats.Cap)
+	}
+	h.Put(NewElem(1))
+	defer func() {
+		if nil == recover() {
+			t.Fatal("expected WarmUp to panic on a non-empty heap")
+		}
+	}()
+	h.WarmUp(8)
+}
+
+func TestPeekLast(t *testing.T) {
+	if _, ok := NewMinHeap().PeekLast(); ok {
+		t.Fatal("expected false for an empty heap")
+	}
+	rng := rand.New(rand.NewSource(11))
+	for round := 0; round < 50; round++ {
+		h := NewMinHeap()
+		worst := -1
+		for i := 1 + rng.Intn(40); i > 0; i-- {
+			v := rng.Intn(1000)
+			worst = max(worst, v)
+			h.Put(NewElem(v))
+		}
+		last, ok := h.PeekLast()
+		if !ok || last.(*IntElem).data != worst {
+			t.Fatalf("expected %d as the worst element, got %v", worst, last)
+		}
+	}
+}
+
+func TestForEachParallel(t *testing.T) {
+	h := newIntMaxHeap(t)
+	for i := 0; i < 1000; i++ {
+		h.Put(NewElem(i * 31 % 997))
+	}
+	checksum := func(v int) uint64 {
+		return uint64(v)*2654435761 + 1
+	}
+	var want uint64
+	for _, obj := range h.objects {
+		want += checksum(obj.Interface().(*IntElem).data)
+	}
+	for _, workers := range []int{0, 1, 3, 8, 5000} {
+		var got atomic.Uint64
+		var calls atomic.Int64
+		h.ForEachParallel(workers, func(i interface{}) {
+			got.Add(checksum(i.(*IntElem).data))
+			calls.Add(1)
+		})
+		if got.Load() != want || calls.Load() != 1000 {
+			t.Fatalf("%d workers: expected checksum %d over 1000 calls, got %d over %d", workers, want, got.Load(), calls.Load())
+		}
+	}
+	NewMinHeap().ForEachParallel(4, func(interface{}) {
+		t.Fatal("expected no calls for an empty heap")
+	})
+}
+
+func TestEnumerate(t *testing.T) {
+	for levels := 1; levels <= 4; levels++ {
+		size := 1<<levels - 1
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			h := newIntMaxHeap(t)
+			for i := 0; i < size; i++ {
+				h.Put(NewElem(i))
+			}
+			entries := h.Enumerate()
+			if len(entries) != size {
+				t.Fatalf("expected %d entries, got %d", size, len(entries))
+			}
+			perDepth := make([]int, levels)
+			for index, entry := range entries {
+				if entry.Index != index || entry.Value != h.objects[index].Interface() {
+					t.Fatalf("entry %d does not describe its backing slice position", index)
+				}
+				perDepth[entry.Depth]++
+				// in a perfect tree exactly the last level consists of leaves
+				if entry.IsLeaf != (entry.Depth == levels-1) {
+					t.Fatalf("entry %d at depth %d reports IsLeaf=%v", index, entry.Depth, entry.IsLeaf)
+				}
+			}
+			for depth, count := range perDepth {
+				if count != 1<<depth {
+					t.Fatalf("expected %d entries at depth %d, got %d", 1<<depth, depth, count)
+				}
+			}
+		})
+	}
+}
+
+func TestValueComparator(t *testing.T) {
+	for name, compareFn := range map[string]interface{}{
+		"values": func(a, b IntElem) bool { return a.data < b.data },
+		"mixed":  func(a IntElem, b *IntElem) bool { return a.data < b.data },
+	} {
+		if _, err := NewHeap(compareFn); err != ErrMustBePointerReceiver {
+			t.Fatalf("%s: expected ErrMustBePointerReceiver, got %v", name, err)
+		}
+	}
+}
+
+func TestVariadicComparator(t *testing.T) {
+	_, err := NewHeap(func(items ...*IntElem) bool { return false })
+	if err != ErrVariadicComparator {
+		t.Fatalf("expected ErrVariadicComparator, got %v", err)
+	}
+	if err.Error() != "comparator must not be variadic" {
+		t.Fatalf("unexpected message %q", err)
+	}
+}
+
+func TestSwapTop(t *testing.T) {
+	if _, ok := NewMinHeap().SwapTop(NewElem(1)); ok {
+		t.Fatal("expected false for an empty heap")
+	}
+	rng := rand.New(rand.NewSource(9))
+	h := NewMinHeap()
+	for i := 0; i < 50; i++ {
+		h.Put(NewElem(rng.Intn(1000)))
+	}
+	for round := 0; round < 200; round++ {
+		var minimum IntElem
+		h.Peek(&minimum)
+		old, ok := h.SwapTop(NewElem(rng.Intn(1000)))
+		if !ok || old.(*IntElem).data != minimum.data {
+			t.Fatalf("expected the previous minimum %d, got %v", minimum.data, old)
+		}
+		if _, found := h.IndexOf(old); found {
+			t.Fatal("expected the old top to leave the heap")
+		}
+	}
+	mustBeHealthy(t, h)
+	if h.Len() != 50 {
+		t.Fatalf("expected SwapTop to keep the size, got %d", h.Len())
+	}
+}
+
+func TestPrioritize(t *testing.T) {
+	rng := rand.New(rand.NewSource(12))
+	items := make([]interface{}, 30)
+	for index := range items {
+		items[index] = NewElem(rng.Intn(20))
+	}
+	h := newIntMaxHeap(t, 100, 200)
+	got, err := h.Prioritize(items)
+	if nil != err {
+		t.Fatal(err)
+	}
+	reference := NewMaxHeap()
+	for _, item := range items {
+		reference.Put(item)
+	}
+	for index, item := range got {
+		// equal priorities may come in any order, so compare the values
+		if want := reference.pop(); want.(*IntElem).data != item.(*IntElem).data {
+			t.Fatalf("rank %d: expected %d, got %d", index, want.(*IntElem).data, item.(*IntElem).data)
+		}
+	}
+	if len(got) != len(items) {
+		t.Fatalf("expected %d elements, got %d", len(items), len(got))
+	}
+	if values := drainInts(h); !reflect.DeepEqual(values, []int{200, 100}) {
+		t.Fatalf("expected the receiver to be untouched, got %v", values)
+	}
+	if _, err := h.Prioritize([]interface{}{NewInt64Elem(1)}); err != ErrTypeMismatch {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+}
+
+func TestInvert(t *testing.T) {
+	h := newIntMaxHeap(t, 5, 2, 8, 1, 9, 4)
+	inv := h.Invert()
+	mustBeHealthy(t, inv)
+	inverted := drainInts(inv)
+	original := drainInts(h)
+	if len(original) != 6 {
+		t.Fatalf("expected Invert to leave the original intact, got %v", original)
+	}
+	for index, v := range original {
+		if inverted[len(inverted)-1-index] != v {
+			t.Fatalf("expected %v reversed, got %v", original, inverted)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	one, two, three := NewElem(1), NewElem(2), NewElem(3)
+	h := newIntMaxHeap(t)
+	for _, item := range []*IntElem{one, two, three} {
+		h.Put(item)
+	}
+	// 1 is put first, 2 swaps with it, 3 swaps with 2
+	want := map[interface{}]int{three: 0, one: 1, two: 2}
+	if got := h.Index(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for item, position := range want {
+		if index, ok := h.IndexOf(item); !ok || index != position {
+			t.Fatalf("IndexOf: expected %d, got %d", position, index)
+		}
+	}
+	if _, ok := h.IndexOf(NewElem(1)); ok {
+		t.Fatal("expected IndexOf to miss an element outside the heap")
+	}
+	snapshot := h.Index()
+	h.pop()
+	if len(snapshot) != 3 {
+		t.Fatal("expected the snapshot to stay unaffected by later changes")
+	}
+}
+
+type refCounted struct {
+	priority int
+	refs     int
+}
+
+func TestEvictCallbackReleasesAll(t *testing.T) {
+	h, err := NewHeapWithEvictCallback(func(a, b *refCounted) bool {
+		return a.priority > b.priority
+	}, 3, func(item interface{}) {
+		item.(*refCounted).refs--
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	var first []*refCounted
+	for priority := 1; priority <= 3; priority++ {
+		item := &refCounted{priority: priority, refs: 1}
+		first = append(first, item)
+		h.Put(item)
+	}
+	for priority := 4; priority <= 6; priority++ {
+		h.Put(&refCounted{priority: priority, refs: 1})
+	}
+	for _, item := range first {
+		if item.refs != 0 {
+			t.Fatalf("expected element %d to be released, it holds %d references", item.priority, item.refs)
+		}
+	}
+	for _, obj := range h.objects {
+		if obj.Interface().(*refCounted).refs != 1 {
+			t.Fatal("expected the kept elements to be untouched")
+		}
+	}
+}
+
+func TestSplitAt(t *testing.T) {
+	rng := rand.New(rand.NewSource(13))
+	h := NewMinHeap()
+	for i := 0; i < 40; i++ {
+		h.Put(NewElem(rng.Intn(100)))
+	}
+	top, rest, err := h.SplitAt(15)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if top.Len() != 15 || top.Len()+rest.Len() != h.Len() {
+		t.Fatalf("expected 15 + 25 elements, got %d + %d", top.Len(), rest.Len())
+	}
+	mustBeHealthy(t, h)
+	mustBeHealthy(t, top)
+	mustBeHealthy(t, rest)
+	worstOfTop, _ := top.PeekLast()
+	var bestOfRest IntElem
+	rest.Peek(&bestOfRest)
+	if worstOfTop.(*IntElem).data > bestOfRest.data {
+		t.Fatalf("top holds %d, rest holds the better %d", worstOfTop.(*IntElem).data, bestOfRest.data)
+	}
+	if h.Len() != 40 {
+		t.Fatalf("expected SplitAt to keep the original intact, got %d elements", h.Len())
+	}
+	for _, n := range []int{-1, 41} {
+		if _, _, err := h.SplitAt(n); nil == err {
+			t.Fatalf("expected an error for n=%d", n)
+		}
+	}
+}
+
+func TestMapInPlace(t *testing.T) {
+	h := newIntMaxHeap(t, 3, 7, 1, 5)
+	if err := h.MapInPlace(func(i interface{}) interface{} {
+		i.(*IntElem).data += 100
+		return i
+	}); nil != err {
+		t.Fatal(err)
+	}
+	mustBeHealthy(t, h)
+	// negating reverses the order, so the heap has to be rebuilt
+	if err := h.MapInPlace(func(i interface{}) interface{} {
+		return NewElem(-i.(*IntElem).data)
+	}); nil != err {
+		t.Fatal(err)
+	}
+	mustBeHealthy(t, h)
+	if err := h.MapInPlace(func(i interface{}) interface{} {
+		return NewInt64Elem(0)
+	}); err != ErrTypeMismatch {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+	if got, want := drainInts(h), []int{-101, -103, -105, -107}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestToMapAndToSet(t *testing.T) {
+	h := newIntMaxHeap(t, 4, 2, 9, 2)
+	set := h.ToSet()
+	if len(set) != h.Len() {
+		t.Fatalf("expected %d set entries, got %d", h.Len(), len(set))
+	}
+	m := h.ToMap()
+	if len(m) != h.Len() {
+		t.Fatalf("expected %d map entries, got %d", h.Len(), len(m))
+	}
+	for _, obj := range h.objects {
+		item := obj.Interface()
+		if _, ok := set[item]; !ok {
+			t.Fatal("expected every element in the set")
+		}
+		if m[item] != item {
+			t.Fatal("expected every element to map to itself")
+		}
+	}
+}
+
+func TestPopAndPush(t *testing.T) {
+	rng := rand.New(rand.NewSource(14))
+	h := NewMinHeap()
+	for i := 0; i < 100; i++ {
+		h.Put(NewElem(rng.Intn(1000)))
+	}
+	// pushing only values at least as large as the popped ones keeps the
+	// output sorted
+	last := -1
+	for i := 0; i < 1000; i++ {
+		var top IntElem
+		h.Peek(&top)
+		old, err := h.PopAndPush(NewElem(top.data + rng.Intn(50)))
+		if nil != err {
+			t.Fatal(err)
+		}
+		if v := old.(*IntElem).data; v < last {
+			t.Fatalf("popped %d after %d", v, last)
+		} else {
+			last = v
+		}
+	}
+	mustBeHealthy(t, h)
+	if h.Len() != 100 {
+		t.Fatalf("expected 100 elements, got %d", h.Len())
+	}
+	if _, err := NewMinHeap().PopAndPush(NewElem(1)); err != ErrEmptyHeap {
+		t.Fatalf("expected ErrEmptyHeap, got %v", err)
+	}
+}
+
+type keyed struct {
+	key      string
+	priority int
+}
+
+type hashedKeyed struct {
+	keyed
+}
+
+func (k *hashedKeyed) Hash() uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(k.key))
+	return hash.Sum64()
+}
+
+func TestDeduplicate(t *testing.T) {
+	sameKey := func(a, b interface{}) bool {
+		return reflect.ValueOf(a).Elem().FieldByName("key").String() ==
+			reflect.ValueOf(b).Elem().FieldByName("key").String()
+	}
+	for _, test := range []struct {
+		name string
+		h    *Heap
+		elem func(key string, priority int) interface{}
+	}{
+		{"pairwise", MustHeap(func(a, b *keyed) bool { return a.priority > b.priority }),
+			func(key string, priority int) interface{} { return &keyed{key, priority} }},
+		{"hashed", MustHeap(func(a, b *hashedKeyed) bool { return a.priority > b.priority }),
+			func(key string, priority int) interface{} { return &hashedKeyed{keyed{key, priority}} }},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for index, key := range []string{"a", "b", "c", "d", "e"} {
+				test.h.Put(test.elem(key, index))
+				test.h.Put(test.elem(key, index+10))
+			}
+			if removed := test.h.Deduplicate(sameKey); removed != 5 || test.h.Len() != 5 {
+				t.Fatalf("expected 5 of 10 elements removed, removed %d and kept %d", removed, test.h.Len())
+			}
+			mustBeHealthy(t, test.h)
+			seen := make(map[string]bool)
+			for _, obj := range test.h.objects {
+				elem := obj.Interface()
+				if hashed, ok := elem.(*hashedKeyed); ok {
+					elem = &hashed.keyed
+				}
+				kept := elem.(*keyed)
+				if seen[kept.key] {
+					t.Fatalf("key %s kept twice", kept.key)
+				}
+				seen[kept.key] = true
+				if kept.priority < 10 {
+					t.Fatalf("expected the higher priority copy of %s to be kept", kept.key)
+				}
+			}
+		})
+	}
+}
+
+func TestSampleUniform(t *testing.T) {
+	h := NewMinHeap()
+	for i := 0; i < 10; i++ {
+		h.Put(NewElem(i))
+	}
+	rng := rand.New(rand.NewSource(1))
+	const rounds, size = 10000, 3
+	seen := make(map[int]int)
+	for round := 0; round < rounds; round++ {
+		sample := h.Sample(size, rng)
+		if len(sample) != size {
+			t.Fatalf("expected %d elements, got %d", size, len(sample))
+		}
+		for _, item := range sample {
+			seen[item.(*IntElem).data]++
+		}
+	}
+	expected := rounds * size / 10
+	for i := 0; i < 10; i++ {
+		if seen[i] < expected*9/10 || seen[i] > expected*11/10 {
+			t.Fatalf("element %d sampled %d times, expected about %d", i, seen[i], expected)
+		}
+	}
+	if h.Len() != 10 {
+		t.Fatal("Sample modified the heap")
+	}
+	mustBeHealthy(t, h)
+	if got := len(h.Sample(20, rng)); got != 10 {
+		t.Fatalf("expected the sample to be capped at 10 elements, got %d", got)
+	}
+}
+
+func TestPrioritizeKeys(t *testing.T) {
+	h := newIntMaxHeap(t, 4, 8, 1, 6, 3)
+	var elem IntElem
+	h.Get(&elem)
+	keys := []interface{}{h.objects[2].Interface(), NewElem(8), h.objects[0].Interface(), h.objects[3].Interface()}
+
+	positions, found := h.PrioritizeKeys(keys)
+	expectedFound := []bool{true, false, true, true}
+	for index, key := range keys {
+		if found[index] != expectedFound[index] {
+			t.Fatalf("key %d: expected found %v", index, expectedFound[index])
+		}
+		if found[index] && h.objects[positions[index]].Interface() != key {
+			t.Fatalf("key %d reported at position %d holding %v", index, positions[index], h.objects[positions[index]].Interface())
+		}
+	}
+}
+
+func TestMinMaxElem(t *testing.T) {
+	for _, h := range []*Heap{NewMinHeap(), NewMaxHeap()} {
+		if _, ok := h.MinElem(); ok {
+			t.Fatal("expected false for an empty heap")
+		}
+		if _, ok := h.MaxElem(); ok {
+			t.Fatal("expected false for an empty heap")
+		}
+	}
+	rng := rand.New(rand.NewSource(5))
+	for round := 0; round < 50; round++ {
+		minHeap, maxHeap := NewMinHeap(), NewMaxHeap()
+		lo, hi := 1000, -1
+		for i := 1 + rng.Intn(40); i > 0; i-- {
+			v := rng.Intn(1000)
+			lo, hi = min(lo, v), max(hi, v)
+			minHeap.Put(NewElem(v))
+			maxHeap.Put(NewElem(v))
+		}
+		for _, test := range []struct {
+			h           *Heap
+			first, last int
+		}{{minHeap, lo, hi}, {maxHeap, hi, lo}} {
+			first, _ := test.h.MinElem()
+			last, _ := test.h.MaxElem()
+			if first.(*IntElem).data != test.first || last.(*IntElem).data != test.last {
+				t.Fatalf("expected %d and %d, got %v and %v", test.first, test.last, first, last)
+			}
+		}
+		if minHeap.Len() != maxHeap.Len() || minHeap.Len() == 0 {
+			t.Fatal("MinElem or MaxElem removed elements")
+		}
+	}
+}
+
+func BenchmarkParallelBulkBuild(b *testing.B) {
+	const n = 1000000
+	elems := make([]*IntElem, n)
+	items := make([]interface{}, n)
+	for i := range items {
+		elems[i] = NewElem(i * 7919 % n)
+		items[i] = elems[i]
+	}
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := NewMinHeap().PushFromSlice(elems); nil != err {
+				b.Fatal(err)
+			}
+		}
+	})
+	for _, workers := range []int{4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := NewMinHeap().ParallelBulkBuild(items, workers); nil != err {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestTreeString(t *testing.T) {
+	h := MustHeap(func(a, b *gobElem) bool {
+		return a.N < b.N
+	})
+	if got := h.TreeString(); got != "Heap(len=0)" {
+		t.Fatalf("unexpected rendering of an empty heap: %q", got)
+	}
+	for i := 1; i <= 7; i++ {
+		h.Put(&gobElem{i})
+	}
+	expected := `&{1}
+├── &{2}
+│   ├── &{4}
+│   └── &{5}
+└── &{3}
+    ├── &{6}
+    └── &{7}
+`
+	if got := h.TreeString(); got != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, got)
+	}
+	for i := 8; i <= 64; i++ {
+		h.Put(&gobElem{i})
+	}
+	if got := h.TreeString(); got != "Heap(len=64, top=&{1}, ...)" {
+		t.Fatalf("unexpected rendering of a large heap: %q", got)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	h := newIntMaxHeap(t, 5, 3, 9, 1, 7)
+	before := make(map[interface{}]bool)
+	for _, obj := range h.objects {
+		before[obj.Interface()] = true
+	}
+	h.Rotate(3, func(item interface{}) interface{} { return item })
+	h.Rotate(2, nil)
+	mustBeHealthy(t, h)
+	if h.Len() != len(before) {
+		t.Fatalf("expected %d elements, got %d", len(before), h.Len())
+	}
+	for _, obj := range h.objects {
+		if !before[obj.Interface()] {
+			t.Fatalf("unexpected element %v after rotating", obj.Interface())
+		}
+	}
+
+	h.Rotate(2, func(item interface{}) interface{} {
+		return NewElem(item.(*IntElem).data - 10)
+	})
+	mustBeHealthy(t, h)
+	if got, want := drainInts(h), []int{5, 3, 1, -1, -3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestPeekPriority(t *testing.T) {
+	data := func(item interface{}) interface{} {
+		return item.(*IntElem).data
+	}
+	h := NewMaxHeap()
+	if _, ok := h.PeekPriority(data); ok {
+		t.Fatal("expected false for an empty heap")
+	}
+	for _, v := range []int{4, 11, 2} {
+		h.Put(NewElem(v))
+	}
+	priority, ok := h.PeekPriority(data)
+	if !ok || priority != 11 {
+		t.Fatalf("expected priority 11, got %v", priority)
+	}
+	var top IntElem
+	if !h.Peek(&top) || top.data != priority || h.Len() != 3 {
+		t.Fatalf("PeekPriority disagrees with Peek or removed the top: %d", top.data)
+	}
+}
+
+func TestNewHeapForType(t *testing.T) {
+	h, err := NewHeapForType(reflect.TypeOf((*IntElem)(nil)), func(a, b *IntElem) bool {
+		return a.data < b.data
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, v := range []int{3, 1, 2} {
+		h.Put(NewElem(v))
+	}
+	mustBeHealthy(t, h)
+	if got, want := drainInts(h), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for _, elementType := range []reflect.Type{nil, reflect.TypeOf(IntElem{})} {
+		if _, err := NewHeapForType(elementType, lessIntElem); err != ErrMustBePointerReceiver {
+			t.Fatalf("%v: expected ErrMustBePointerReceiver, got %v", elementType, err)
+		}
+	}
+	if _, err := NewHeapForType(reflect.TypeOf((*gobElem)(nil)), lessIntElem); err != ErrTypeMismatch {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+}
+
+func TestInsertionOrder(t *testing.T) {
+	h := newTrackedMaxHeap(t)
+	var elems []interface{}
+	for _, v := range []int{5, 2, 9, 1, 7} {
+		elem := NewElem(v)
+		elems = append(elems, elem)
+		h.Put(elem)
+	}
+	replacement := NewElem(3)
+	h.Replace(elems[1], replacement)
+	elems[1] = replacement
+	h.DeleteElem(elems[3])
+	elems = append(elems[:3], elems[4])
+	elems[0].(*IntElem).data = 0
+	h.Update(elems[0])
+
+	if got := h.InsertionOrder(); !reflect.DeepEqual(got, elems) {
+		t.Fatalf("expected %v, got %v", elemValues(elems), elemValues(got))
+	}
+	if got := h.Copy().InsertionOrder(); !reflect.DeepEqual(elemValues(got), elemValues(elems)) {
+		t.Fatalf("copy lost the insertion order: %v", elemValues(got))
+	}
+}
+
+func TestInsertionOrderRequiresTracking(t *testing.T) {
+	defer func() {
+		if r := recover(); r != ErrInsertionTrackingDisabled {
+			t.Fatalf("expected ErrInsertionTrackingDisabled, got %v", r)
+		}
+	}()
+	newIntMaxHeap(t, 1, 2).InsertionOrder()
+}
+
+func TestRebalance(t *testing.T) {
+	h := newIntMaxHeap(t, 8, 6, 7, 1, 2, 3, 4)
+	elems := make([]*IntElem, h.Len())
+	for index, obj := range h.objects {
+		elems[index] = obj.Interface().(*IntElem)
+	}
+	// mutate without telling the heap
+	elems[len(elems)-1].data = 20
+	elems[0].data = 0
+	if nil == h.HealthCheck() {
+		t.Fatal("expected the mutations to break the heap invariant")
+	}
+	h.Rebalance()
+	mustBeHealthy(t, h)
+	if top, _ := h.MinElem(); top != elems[len(elems)-1] {
+		t.Fatalf("expected the mutated element on top, got %v", top)
+	}
+
+	elems[3].data = 30
+	if nil == h.HealthCheck() {
+		t.Fatal("expected the mutation to break the heap invariant")
+	}
+	if !h.RebalanceOne(elems[3]) {
+		t.Fatal("RebalanceOne did not find the element")
+	}
+	mustBeHealthy(t, h)
+	if top, _ := h.MinElem(); top != elems[3] {
+		t.Fatalf("expected the mutated element on top, got %v", top)
+	}
+	if h.RebalanceOne(NewElem(1)) {
+		t.Fatal("expected false for an element outside the heap")
+	}
+}
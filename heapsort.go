@@ -0,0 +1,71 @@
+package heap
+
+import (
+	"reflect"
+)
+
+// SortInPlace heap sorts the backing slice into priority order. A slice sorted
+// that way satisfies the heap property, so the heap stays usable afterwards.
+func (h *Heap) SortInPlace() {
+	heapSort(len(h.objects), func(i, j int) bool {
+		return h.compare(h.objects[i], h.objects[j])
+	}, func(i, j int) {
+		h.objects[i], h.objects[j] = h.objects[j], h.objects[i]
+	})
+	h.reindex()
+}
+
+// Sort returns the elements in priority order without modifying the heap.
+func (h *Heap) Sort() []interface{} {
+	objects := make([]reflect.Value, len(h.objects))
+	copy(objects, h.objects)
+	heapSort(len(objects), func(i, j int) bool {
+		return h.compare(objects[i], objects[j])
+	}, func(i, j int) {
+		objects[i], objects[j] = objects[j], objects[i]
+	})
+	ret := make([]interface{}, len(objects))
+	for index, obj := range objects {
+		ret[index] = obj.Interface()
+	}
+	return ret
+}
+
+// HeapSort sorts items in place so that less(items[i], items[j]) never holds
+// for i > j.
+func HeapSort(items []interface{}, less func(a, b interface{}) bool) {
+	heapSort(len(items), func(i, j int) bool {
+		return less(items[i], items[j])
+	}, func(i, j int) {
+		items[i], items[j] = items[j], items[i]
+	})
+}
+
+// heapSort builds a heap with the greatest element on top and repeatedly moves
+// the top to the end of the unsorted range.
+func heapSort(n int, less func(i, j int) bool, swap func(i, j int)) {
+	for root := n/2 - 1; root >= 0; root-- {
+		siftDown(root, n, less, swap)
+	}
+	for end := n - 1; end > 0; end-- {
+		swap(0, end)
+		siftDown(0, end, less, swap)
+	}
+}
+
+func siftDown(root, n int, less func(i, j int) bool, swap func(i, j int)) {
+	for {
+		child := 2*root + 1
+		if child >= n {
+			return
+		}
+		if child+1 < n && less(child, child+1) {
+			child++
+		}
+		if !less(root, child) {
+			return
+		}
+		swap(root, child)
+		root = child
+	}
+}
@@ -0,0 +1,117 @@
+package heap
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSortInPlace(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	h := NewMaxHeap()
+	for i := 0; i < 200; i++ {
+		h.Put(NewElem(rng.Intn(100)))
+	}
+	sorted := h.Sort()
+	if h.Len() != 200 {
+		t.Fatalf("expected Sort to keep the elements, got %d", h.Len())
+	}
+	h.SortInPlace()
+	mustBeHealthy(t, h)
+	for index, obj := range h.objects {
+		if index > 0 && obj.Interface().(*IntElem).data > h.objects[index-1].Interface().(*IntElem).data {
+			t.Fatalf("index %d is out of priority order", index)
+		}
+		if sorted[index].(*IntElem).data != obj.Interface().(*IntElem).data {
+			t.Fatalf("Sort and SortInPlace disagree at index %d", index)
+		}
+	}
+}
+
+func TestHeapSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(5))
+	for _, n := range []int{0, 1, 2, 101} {
+		items := make([]interface{}, n)
+		for index := range items {
+			items[index] = rng.Intn(50)
+		}
+		HeapSort(items, func(a, b interface{}) bool {
+			return a.(int) < b.(int)
+		})
+		if !sort.SliceIsSorted(items, func(i, j int) bool { return items[i].(int) < items[j].(int) }) {
+			t.Fatalf("%d items not sorted: %v", n, items)
+		}
+	}
+}
+
+func benchmarkItems(n int) []interface{} {
+	rng := rand.New(rand.NewSource(1))
+	items := make([]interface{}, n)
+	for index := range items {
+		items[index] = rng.Int()
+	}
+	return items
+}
+
+func BenchmarkHeapSort(b *testing.B) {
+	src := benchmarkItems(100000)
+	items := make([]interface{}, len(src))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(items, src)
+		HeapSort(items, func(a, b interface{}) bool { return a.(int) < b.(int) })
+	}
+}
+
+func BenchmarkSortSlice(b *testing.B) {
+	src := benchmarkItems(100000)
+	items := make([]interface{}, len(src))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(items, src)
+		sort.Slice(items, func(i, j int) bool { return items[i].(int) < items[j].(int) })
+	}
+}
+
+func TestSort(t *testing.T) {
+	h := newIntMaxHeap(t, 4, 9, 1, 7, 7, 3)
+	if got, want := elemValues(h.Sort()), []int{9, 7, 7, 4, 3, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	mustBeHealthy(t, h)
+	if h.Len() != 6 {
+		t.Fatalf("expected Sort to keep the elements, got %d", h.Len())
+	}
+}
+
+func sortBenchmarkHeap() *Heap {
+	rng := rand.New(rand.NewSource(1))
+	h := NewMinHeap()
+	for i := 0; i < 10000; i++ {
+		h.Put(NewElem(rng.Int()))
+	}
+	return h
+}
+
+func BenchmarkHeapSortMethod(b *testing.B) {
+	h := sortBenchmarkHeap()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Sort()
+	}
+}
+
+func BenchmarkSortSliceElems(b *testing.B) {
+	h := sortBenchmarkHeap()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		items := make([]interface{}, len(h.objects))
+		for index, obj := range h.objects {
+			items[index] = obj.Interface()
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].(*IntElem).data < items[j].(*IntElem).data
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package heap
+
+import (
+	coheap "container/heap"
+)
+
+// IndexedPQ is a priority queue of keys ordered by their values. Keys are
+// tracked in a typed map, so no reflection is involved.
+type IndexedPQ[K comparable, V any] struct {
+	items pqItems[K, V]
+}
+
+type pqEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+type pqItems[K comparable, V any] struct {
+	entries []pqEntry[K, V]
+	index   map[K]int
+	less    PriorityFunc[V]
+}
+
+func (p *pqItems[K, V]) Len() int {
+	return len(p.entries)
+}
+
+func (p *pqItems[K, V]) Less(i, j int) bool {
+	return p.less(p.entries[i].value, p.entries[j].value)
+}
+
+func (p *pqItems[K, V]) Swap(i, j int) {
+	p.index[p.entries[i].key] = j
+	p.index[p.entries[j].key] = i
+	p.entries[i], p.entries[j] = p.entries[j], p.entries[i]
+}
+
+func (p *pqItems[K, V]) Push(i interface{}) {
+	entry := i.(pqEntry[K, V])
+	p.index[entry.key] = len(p.entries)
+	p.entries = append(p.entries, entry)
+}
+
+func (p *pqItems[K, V]) Pop() interface{} {
+	length := len(p.entries)
+	ret := p.entries[length-1]
+	delete(p.index, ret.key)
+	p.entries[length-1] = pqEntry[K, V]{}
+	p.entries = p.entries[:length-1]
+	return ret
+}
+
+func NewIndexedPQ[K comparable, V any](less PriorityFunc[V]) *IndexedPQ[K, V] {
+	return &IndexedPQ[K, V]{
+		items: pqItems[K, V]{
+			index: make(map[K]int),
+			less:  less,
+		},
+	}
+}
+
+// Put adds key with the given value or updates the value if key is present.
+func (q *IndexedPQ[K, V]) Put(key K, value V) {
+	if !q.UpdatePriority(key, value) {
+		coheap.Push(&q.items, pqEntry[K, V]{key, value})
+	}
+}
+
+func (q *IndexedPQ[K, V]) UpdatePriority(key K, value V) bool {
+	index, ok := q.items.index[key]
+	if !ok {
+		return false
+	}
+	q.items.entries[index].value = value
+	coheap.Fix(&q.items, index)
+	return true
+}
+
+func (q *IndexedPQ[K, V]) DeleteKey(key K) bool {
+	index, ok := q.items.index[key]
+	if !ok {
+		return false
+	}
+	coheap.Remove(&q.items, index)
+	return true
+}
+
+func (q *IndexedPQ[K, V]) Contains(key K) bool {
+	_, ok := q.items.index[key]
+	return ok
+}
+
+func (q *IndexedPQ[K, V]) Peek() (K, V, bool) {
+	if q.items.Len() == 0 {
+		var key K
+		var value V
+		return key, value, false
+	}
+	entry := q.items.entries[0]
+	return entry.key, entry.value, true
+}
+
+func (q *IndexedPQ[K, V]) Pop() (K, V, bool) {
+	if q.items.Len() == 0 {
+		var key K
+		var value V
+		return key, value, false
+	}
+	entry := coheap.Pop(&q.items).(pqEntry[K, V])
+	return entry.key, entry.value, true
+}
+
+func (q *IndexedPQ[K, V]) Len() int {
+	return q.items.Len()
+}
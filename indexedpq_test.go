@@ -0,0 +1,65 @@
+package heap
+
+import (
+	"testing"
+)
+
+func TestIndexedPQ(t *testing.T) {
+	q := NewIndexedPQ[string](Ascending[int]())
+	q.Put("a", 5)
+	q.Put("b", 3)
+	q.Put("c", 9)
+	q.Put("a", 1)
+	if q.Len() != 3 {
+		t.Fatalf("expected putting a present key to update it, got %d keys", q.Len())
+	}
+	if !q.Contains("c") || q.Contains("d") {
+		t.Fatal("unexpected Contains result")
+	}
+	if !q.UpdatePriority("c", 0) || q.UpdatePriority("d", 0) {
+		t.Fatal("expected UpdatePriority to succeed only for present keys")
+	}
+	if !q.DeleteKey("b") || q.DeleteKey("b") || q.Contains("b") {
+		t.Fatal("expected b to be deleted exactly once")
+	}
+	if key, value, ok := q.Peek(); !ok || key != "c" || value != 0 {
+		t.Fatalf("expected c with 0 on top, got %s with %d", key, value)
+	}
+	for _, want := range []string{"c", "a"} {
+		if key, _, ok := q.Pop(); !ok || key != want {
+			t.Fatalf("expected %s, got %s", want, key)
+		}
+	}
+	if _, _, ok := q.Pop(); ok {
+		t.Fatal("expected Pop on an empty queue to return false")
+	}
+	if _, _, ok := q.Peek(); ok {
+		t.Fatal("expected Peek on an empty queue to return false")
+	}
+}
+
+func BenchmarkIndexedPQ(b *testing.B) {
+	q := NewIndexedPQ[int](Ascending[int]())
+	for i := 0; i < 1024; i++ {
+		q.Put(i, i*7919%1024)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q.UpdatePriority(i%1024, i%4096)
+	}
+}
+
+func BenchmarkReflectHeapUpdate(b *testing.B) {
+	h := NewMinHeap()
+	items := make([]*IntElem, 1024)
+	for index := range items {
+		items[index] = NewElem(index * 7919 % 1024)
+		h.Put(items[index])
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		item := items[i%1024]
+		item.data = i % 4096
+		h.Update(item)
+	}
+}
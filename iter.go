@@ -0,0 +1,32 @@
+//go:build go1.23
+
+package heap
+
+import (
+	coheap "container/heap"
+	"iter"
+)
+
+// Iter2 yields the backing slice position and element of every item.
+func (h *Heap) Iter2() iter.Seq2[int, interface{}] {
+	return func(yield func(int, interface{}) bool) {
+		for index, obj := range h.objects {
+			if !yield(index, obj.Interface()) {
+				return
+			}
+		}
+	}
+}
+
+// SortedIter2 yields the elements in priority order together with their pop
+// rank. The heap itself is not modified.
+func (h *Heap) SortedIter2() iter.Seq2[int, interface{}] {
+	return func(yield func(int, interface{}) bool) {
+		c := h.clone()
+		for rank := 0; c.Len() > 0; rank++ {
+			if !yield(rank, coheap.Pop(c)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+//go:build go1.23
+
+package heap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIter2(t *testing.T) {
+	h := newIntMaxHeap(t, 5, 3, 8, 1)
+	visited := 0
+	for index, item := range h.Iter2() {
+		if h.objects[index].Interface() != item {
+			t.Fatalf("position %d yielded the wrong element", index)
+		}
+		visited++
+		if visited == 2 {
+			break
+		}
+	}
+	if visited != 2 {
+		t.Fatalf("expected the loop to stop after 2 elements, visited %d", visited)
+	}
+}
+
+func TestSortedIter2(t *testing.T) {
+	h := newIntMaxHeap(t, 5, 3, 8, 1)
+	var got []int
+	for rank, item := range h.SortedIter2() {
+		if rank != len(got) {
+			t.Fatalf("expected rank %d, got %d", len(got), rank)
+		}
+		got = append(got, item.(*IntElem).data)
+		if rank == 2 {
+			break
+		}
+	}
+	if want := []int{8, 5, 3}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if h.Len() != 4 {
+		t.Fatalf("expected the heap to keep its 4 elements, got %d", h.Len())
+	}
+	mustBeHealthy(t, h)
+}
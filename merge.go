@@ -0,0 +1,121 @@
+package heap
+
+import (
+	coheap "container/heap"
+	"context"
+	"reflect"
+)
+
+type mergeItem struct {
+	value  reflect.Value
+	source int
+}
+
+// mergeHeap orders the heads of several sources by the comparator of h.
+type mergeHeap struct {
+	items []mergeItem
+	h     *Heap
+}
+
+func (m *mergeHeap) Len() int {
+	return len(m.items)
+}
+
+func (m *mergeHeap) Less(i, j int) bool {
+	return m.h.compare(m.items[i].value, m.items[j].value)
+}
+
+func (m *mergeHeap) Swap(i, j int) {
+	m.items[i], m.items[j] = m.items[j], m.items[i]
+}
+
+func (m *mergeHeap) Push(i interface{}) {
+	m.items = append(m.items, i.(mergeItem))
+}
+
+func (m *mergeHeap) Pop() interface{} {
+	length := len(m.items)
+	ret := m.items[length-1]
+	m.items = m.items[:length-1]
+	return ret
+}
+
+// StreamMerge drains all heaps and emits their elements in global priority
+// order. All heaps must hold the same element type; the comparator of the
+// first heap decides the order. The heaps are consumed by a background
+// goroutine and must not be used until the channel is closed, which happens
+// once all heaps are empty or ctx is done.
+func StreamMerge(ctx context.Context, heaps ...*Heap) <-chan interface{} {
+	out := make(chan interface{})
+	if len(heaps) == 0 {
+		close(out)
+		return out
+	}
+	for _, h := range heaps {
+		if h.dataType != heaps[0].dataType {
+			panic("heaps must share the same element type")
+		}
+	}
+
+	go func() {
+		defer close(out)
+		m := &mergeHeap{h: heaps[0]}
+		for source, h := range heaps {
+			if h.Len() > 0 {
+				m.items = append(m.items, mergeItem{reflect.ValueOf(h.pop()), source})
+			}
+		}
+		coheap.Init(m)
+		for m.Len() > 0 {
+			next := coheap.Pop(m).(mergeItem)
+			select {
+			case out <- next.value.Interface():
+			case <-ctx.Done():
+				return
+			}
+			if source := heaps[next.source]; source.Len() > 0 {
+				coheap.Push(m, mergeItem{reflect.ValueOf(source.pop()), next.source})
+			}
+		}
+	}()
+	return out
+}
+
+// MergeSort merges slices, each already sorted by compareFn, into one sorted
+// slice.
+func MergeSort(slices [][]interface{}, compareFn interface{}) ([]interface{}, error) {
+	h, err := NewHeap(compareFn)
+	if nil != err {
+		return nil, err
+	}
+	total := 0
+	for _, slice := range slices {
+		for _, item := range slice {
+			if reflect.TypeOf(item) != h.dataType {
+				return nil, ErrTypeMismatch
+			}
+		}
+		total += len(slice)
+	}
+
+	m := &mergeHeap{h: h}
+	next := make([]int, len(slices))
+	for source, slice := range slices {
+		if len(slice) > 0 {
+			m.items = append(m.items, mergeItem{reflect.ValueOf(slice[0]), source})
+			next[source] = 1
+		}
+	}
+	coheap.Init(m)
+
+	ret := make([]interface{}, 0, total)
+	for m.Len() > 0 {
+		item := coheap.Pop(m).(mergeItem)
+		ret = append(ret, item.value.Interface())
+		if slice := slices[item.source]; next[item.source] < len(slice) {
+			coheap.Push(m, mergeItem{reflect.ValueOf(slice[next[item.source]]), item.source})
+			next[item.source]++
+		}
+	}
+	return ret, nil
+}
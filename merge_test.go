@@ -0,0 +1,147 @@
+package heap
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamMerge(t *testing.T) {
+	heaps := []*Heap{NewMinHeap(), NewMinHeap(), NewMinHeap()}
+	for v := 0; v < 30; v++ {
+		heaps[v*7%3].Put(NewElem(v))
+	}
+	var got []int
+	for item := range StreamMerge(context.Background(), heaps...) {
+		got = append(got, item.(*IntElem).data)
+	}
+	if len(got) != 30 {
+		t.Fatalf("expected 30 elements, got %d", len(got))
+	}
+	for index, v := range got {
+		if v != index {
+			t.Fatalf("expected %d at position %d, got %v", index, index, got)
+		}
+	}
+	for _, h := range heaps {
+		if h.Len() != 0 {
+			t.Fatalf("expected the sources to be drained, got %d elements left", h.Len())
+		}
+	}
+}
+
+func TestStreamMergeCancel(t *testing.T) {
+	h := NewMinHeap()
+	for v := 0; v < 10; v++ {
+		h.Put(NewElem(v))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	out := StreamMerge(ctx, h)
+	if first := <-out; first.(*IntElem).data != 0 {
+		t.Fatalf("expected 0 first, got %d", first.(*IntElem).data)
+	}
+	cancel()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-out:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("expected the channel to be closed after cancellation")
+		}
+	}
+}
+
+func TestStreamMergeNoHeaps(t *testing.T) {
+	if _, ok := <-StreamMerge(context.Background()); ok {
+		t.Fatal("expected a closed channel")
+	}
+}
+
+func TestMergeSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	slices := make([][]interface{}, 5)
+	for index := range slices {
+		values := make([]int, 200)
+		for i := range values {
+			values[i] = rng.Intn(1000)
+		}
+		sort.Ints(values)
+		for _, v := range values {
+			slices[index] = append(slices[index], NewElem(v))
+		}
+	}
+	merged, err := MergeSort(slices, lessIntElem)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if len(merged) != 1000 {
+		t.Fatalf("expected 1000 elements, got %d", len(merged))
+	}
+	if !sort.SliceIsSorted(merged, func(i, j int) bool {
+		return merged[i].(*IntElem).data < merged[j].(*IntElem).data
+	}) {
+		t.Fatal("expected the merged slice to be sorted")
+	}
+}
+
+func TestMergeSortTypeMismatch(t *testing.T) {
+	_, err := MergeSort([][]interface{}{{NewElem(1)}, {NewInt64Elem(2)}}, lessIntElem)
+	if err != ErrTypeMismatch {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+}
+
+func TestMergeAll(t *testing.T) {
+	rng := rand.New(rand.NewSource(6))
+	h := NewMinHeap()
+	others := make([]*Heap, 5)
+	for index := range others {
+		others[index] = NewMinHeap()
+		for i := 0; i < 200; i++ {
+			others[index].Put(NewElem(rng.Intn(10000)))
+		}
+	}
+	if err := h.MergeAll(others...); nil != err {
+		t.Fatal(err)
+	}
+	if h.Len() != 1000 {
+		t.Fatalf("expected 1000 elements, got %d", h.Len())
+	}
+	mustBeHealthy(t, h)
+	for index, other := range others {
+		if other.Len() != 0 {
+			t.Fatalf("expected heap %d to be empty, got %d elements", index, other.Len())
+		}
+	}
+}
+
+func TestMergeAllRejectsIncompatibleHeaps(t *testing.T) {
+	h := NewMinHeap()
+	compatible := NewMinHeap()
+	compatible.Put(NewElem(1))
+	err := h.MergeAll(compatible, NewMinInt64Heap(), h)
+	if nil == err {
+		t.Fatal("expected an error")
+	}
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("expected ErrTypeMismatch among the errors, got %v", err)
+	}
+	for _, want := range []string{"heap 1:", "heap 2:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in %q", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "heap 0:") {
+		t.Fatalf("expected the compatible heap not to be listed, got %q", err)
+	}
+	if h.Len() != 0 || compatible.Len() != 1 {
+		t.Fatal("expected nothing to be merged")
+	}
+}
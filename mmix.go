@@ -0,0 +1,141 @@
+package heap
+
+import (
+	coheap "container/heap"
+	"reflect"
+)
+
+const (
+	mmMin = iota
+	mmMax
+)
+
+type mmNode struct {
+	value reflect.Value
+	index [2]int
+}
+
+// mmSide is one of the two heaps of a MinMaxIndexedHeap. The max side simply
+// flips the arguments of the comparator.
+type mmSide struct {
+	nodes []*mmNode
+	side  int
+	cmp   *Heap
+}
+
+func (s *mmSide) Len() int {
+	return len(s.nodes)
+}
+
+func (s *mmSide) Less(i, j int) bool {
+	if s.side == mmMax {
+		i, j = j, i
+	}
+	return s.cmp.compare(s.nodes[i].value, s.nodes[j].value)
+}
+
+func (s *mmSide) Swap(i, j int) {
+	s.nodes[i].index[s.side] = j
+	s.nodes[j].index[s.side] = i
+	s.nodes[i], s.nodes[j] = s.nodes[j], s.nodes[i]
+}
+
+func (s *mmSide) Push(i interface{}) {
+	node := i.(*mmNode)
+	node.index[s.side] = len(s.nodes)
+	s.nodes = append(s.nodes, node)
+}
+
+func (s *mmSide) Pop() interface{} {
+	length := len(s.nodes)
+	ret := s.nodes[length-1]
+	s.nodes[length-1] = nil
+	s.nodes = s.nodes[:length-1]
+	return ret
+}
+
+// MinMaxIndexedHeap keeps its elements in two heaps at once, giving O(1)
+// access to both the element the comparator ranks first (min) and last (max)
+// as well as O(log n) deletion by identity. Each element remembers its
+// position in both heaps itself, so elements do not need to implement Indexer.
+type MinMaxIndexedHeap struct {
+	cmp    *Heap
+	sides  [2]mmSide
+	lookup map[reflect.Value]*mmNode
+}
+
+func NewMinMaxIndexedHeap(compareFn interface{}) (*MinMaxIndexedHeap, error) {
+	cmp, err := NewHeap(compareFn)
+	if nil != err {
+		return nil, err
+	}
+	return &MinMaxIndexedHeap{
+		cmp: cmp,
+		sides: [2]mmSide{
+			{side: mmMin, cmp: cmp},
+			{side: mmMax, cmp: cmp},
+		},
+		lookup: make(map[reflect.Value]*mmNode),
+	}, nil
+}
+
+func (m *MinMaxIndexedHeap) Put(i interface{}) {
+	if reflect.TypeOf(i) != m.cmp.dataType {
+		panic("tried to put invalid type")
+	}
+	node := &mmNode{value: reflect.ValueOf(i)}
+	m.lookup[node.value] = node
+	coheap.Push(&m.sides[mmMin], node)
+	coheap.Push(&m.sides[mmMax], node)
+}
+
+func (m *MinMaxIndexedHeap) PeekMin() (interface{}, bool) {
+	return m.peek(mmMin)
+}
+
+func (m *MinMaxIndexedHeap) PeekMax() (interface{}, bool) {
+	return m.peek(mmMax)
+}
+
+func (m *MinMaxIndexedHeap) PopMin() (interface{}, bool) {
+	return m.pop(mmMin)
+}
+
+func (m *MinMaxIndexedHeap) PopMax() (interface{}, bool) {
+	return m.pop(mmMax)
+}
+
+func (m *MinMaxIndexedHeap) DeleteElem(i interface{}) bool {
+	node, ok := m.lookup[reflect.ValueOf(i)]
+	if !ok {
+		return false
+	}
+	m.remove(node)
+	return true
+}
+
+func (m *MinMaxIndexedHeap) Len() int {
+	return len(m.lookup)
+}
+
+func (m *MinMaxIndexedHeap) peek(side int) (interface{}, bool) {
+	if m.Len() == 0 {
+		return nil, false
+	}
+	return m.sides[side].nodes[0].value.Interface(), true
+}
+
+func (m *MinMaxIndexedHeap) pop(side int) (interface{}, bool) {
+	if m.Len() == 0 {
+		return nil, false
+	}
+	node := m.sides[side].nodes[0]
+	m.remove(node)
+	return node.value.Interface(), true
+}
+
+func (m *MinMaxIndexedHeap) remove(node *mmNode) {
+	coheap.Remove(&m.sides[mmMin], node.index[mmMin])
+	coheap.Remove(&m.sides[mmMax], node.index[mmMax])
+	delete(m.lookup, node.value)
+}
@@ -0,0 +1,89 @@
+package heap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// checkMinMax verifies both sides of m against each other and against the
+// reference values of the elements still expected in m.
+func checkMinMax(t *testing.T, m *MinMaxIndexedHeap, present map[*IntElem]bool) {
+	t.Helper()
+	if m.Len() != len(present) || len(m.lookup) != len(present) {
+		t.Fatalf("expected %d elements, got %d with %d lookup entries", len(present), m.Len(), len(m.lookup))
+	}
+	for side := range m.sides {
+		s := &m.sides[side]
+		if len(s.nodes) != len(present) {
+			t.Fatalf("side %d holds %d of %d elements", side, len(s.nodes), len(present))
+		}
+		for index, node := range s.nodes {
+			if node.index[side] != index {
+				t.Fatalf("side %d: node at %d remembers index %d", side, index, node.index[side])
+			}
+			if index > 0 && s.Less(index, (index-1)/2) {
+				t.Fatalf("side %d: heap invariant violated at %d", side, index)
+			}
+			if !present[node.value.Interface().(*IntElem)] {
+				t.Fatalf("side %d holds a removed element", side)
+			}
+		}
+	}
+	if len(present) == 0 {
+		return
+	}
+	lo, hi := -1, -1
+	for item := range present {
+		if lo < 0 || item.data < lo {
+			lo = item.data
+		}
+		hi = max(hi, item.data)
+	}
+	if minItem, _ := m.PeekMin(); minItem.(*IntElem).data != lo {
+		t.Fatalf("expected min %d, got %d", lo, minItem.(*IntElem).data)
+	}
+	if maxItem, _ := m.PeekMax(); maxItem.(*IntElem).data != hi {
+		t.Fatalf("expected max %d, got %d", hi, maxItem.(*IntElem).data)
+	}
+}
+
+func TestMinMaxIndexedHeap(t *testing.T) {
+	m, err := NewMinMaxIndexedHeap(lessIntElem)
+	if nil != err {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(4))
+	present := make(map[*IntElem]bool)
+	var items []*IntElem
+	for round := 0; round < 2000; round++ {
+		switch op := rng.Intn(5); {
+		case op < 2 || len(present) == 0:
+			item := NewElem(rng.Intn(100))
+			items = append(items, item)
+			present[item] = true
+			m.Put(item)
+		case op == 2:
+			item, _ := m.PopMin()
+			delete(present, item.(*IntElem))
+		case op == 3:
+			item, _ := m.PopMax()
+			delete(present, item.(*IntElem))
+		default:
+			item := items[rng.Intn(len(items))]
+			if m.DeleteElem(item) != present[item] {
+				t.Fatalf("DeleteElem disagrees with the presence of %d", item.data)
+			}
+			delete(present, item)
+		}
+		checkMinMax(t, m, present)
+	}
+	for m.Len() > 0 {
+		m.PopMax()
+	}
+	if _, ok := m.PopMin(); ok {
+		t.Fatal("expected PopMin on an empty heap to return false")
+	}
+	if _, ok := m.PeekMax(); ok {
+		t.Fatal("expected PeekMax on an empty heap to return false")
+	}
+}
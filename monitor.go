@@ -0,0 +1,168 @@
+package heap
+
+import (
+	coheap "container/heap"
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"time"
+)
+
+type HeapStats struct {
+	Len            int
+	Cap            int
+	DataType       string
+	IndexerEnabled bool
+	LookupSize     int
+	PushCount      uint64
+	PopCount       uint64
+	DeleteCount    uint64
+	Timestamp      time.Time
+}
+
+// syncStats publishes the fields of h that Stats cannot read atomically. It
+// must be called after every change of the capacity, the lookup size or index
+// tracking.
+func (h *Heap) syncStats() {
+	h.capacity.Store(int64(cap(h.objects)))
+	h.lookupSize.Store(int64(len(h.lookup)))
+	h.indexing.Store(h.indexer)
+}
+
+// Stats reports the current state of the heap without any locking. All fields
+// are read atomically, so Stats may run concurrently with writers that
+// synchronize among themselves, such as those of a SyncHeap.
+//
+// PushCount counts the elements added by Put, SwapTop, Rotate and the bulk
+// pushes PushFromSlice, ParallelBulkBuild, MergeAll and Deserialize.
+// PopCount counts the elements taken from the top by Get, SwapTop and the
+// other popping methods, and DeleteCount the elements removed by DeleteElem.
+// Elements exchanged by Replace, MapInPlace or UnflattenFrom and elements
+// dropped by Compact, Deduplicate or eviction are not counted.
+func (h *Heap) Stats() HeapStats {
+	return HeapStats{
+		Len:            h.Len(),
+		Cap:            int(h.capacity.Load()),
+		DataType:       h.dataType.String(),
+		IndexerEnabled: h.indexing.Load(),
+		LookupSize:     int(h.lookupSize.Load()),
+		PushCount:      h.pushCount.Load(),
+		PopCount:       h.popCount.Load(),
+		DeleteCount:    h.deleteCount.Load(),
+		Timestamp:      time.Now(),
+	}
+}
+
+// Monitor starts a goroutine sending the heap statistics to stats every
+// interval until ctx is done. Samples are dropped while stats is full, and
+// stats must not be closed before ctx is done. Like Stats it takes no locks.
+func (h *Heap) Monitor(ctx context.Context, interval time.Duration, stats chan<- HeapStats) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				sample := h.Stats()
+				sample.Timestamp = now
+				select {
+				case stats <- sample:
+				default:
+				}
+			}
+		}
+	}()
+}
+
+// Valuer is implemented by elements with a numeric priority.
+type Valuer interface {
+	Value() float64
+}
+
+// Histogram divides the range between the smallest and the largest Value of
+// the elements into buckets equal intervals and counts the elements in each.
+func (h *Heap) Histogram(buckets int) ([]int, error) {
+	if buckets <= 0 {
+		return nil, errors.New("buckets must be positive")
+	}
+	if !h.dataType.Implements(reflect.TypeOf((*Valuer)(nil)).Elem()) {
+		return nil, errors.New("elements do not implement Valuer")
+	}
+	counts := make([]int, buckets)
+	if len(h.objects) == 0 {
+		return counts, nil
+	}
+	values := make([]float64, len(h.objects))
+	lo, hi := 0.0, 0.0
+	for index, obj := range h.objects {
+		v := obj.Interface().(Valuer).Value()
+		values[index] = v
+		if index == 0 || v < lo {
+			lo = v
+		}
+		if index == 0 || v > hi {
+			hi = v
+		}
+	}
+	for _, v := range values {
+		bucket := 0
+		if hi > lo {
+			bucket = min(int((v-lo)/(hi-lo)*float64(buckets)), buckets-1)
+		}
+		counts[bucket]++
+	}
+	return counts, nil
+}
+
+type BenchResult struct {
+	PushNsPerOp float64
+	PopNsPerOp  float64
+	PeekNsPerOp float64
+}
+
+// Benchmark gives a rough estimate of the cost of the comparator and element
+// type of h by putting n elements into an empty heap with the same comparator,
+// peeking n times and popping them all again. The elements are copies of those
+// in h, taken in random order so that pushes and pops sift like they would in
+// h. An empty h only provides zero values, which all compare equal and never
+// sift, so the timings then leave out the comparator. h itself is not
+// touched. Use go test -bench for anything more precise.
+func (h *Heap) Benchmark(n int) BenchResult {
+	var res BenchResult
+	if n <= 0 {
+		return res
+	}
+	b := h.derive()
+	items := make([]interface{}, n)
+	for index := range items {
+		item := reflect.New(h.dataType.Elem())
+		if len(h.objects) > 0 {
+			if obj := h.objects[rand.Intn(len(h.objects))]; !obj.IsNil() {
+				item.Elem().Set(obj.Elem())
+			}
+		}
+		items[index] = item.Interface()
+	}
+
+	start := time.Now()
+	for _, item := range items {
+		coheap.Push(b, item)
+	}
+	res.PushNsPerOp = float64(time.Since(start).Nanoseconds()) / float64(n)
+
+	start = time.Now()
+	for index := 0; index < n; index++ {
+		_ = b.objects[0].Interface()
+	}
+	res.PeekNsPerOp = float64(time.Since(start).Nanoseconds()) / float64(n)
+
+	start = time.Now()
+	for b.Len() > 0 {
+		coheap.Pop(b)
+	}
+	res.PopNsPerOp = float64(time.Since(start).Nanoseconds()) / float64(n)
+	return res
+}
@@ -0,0 +1,228 @@
+package heap
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatsCounters(t *testing.T) {
+	h := NewMinHeap()
+	elems := make([]*IntElem, 5)
+	for i := range elems {
+		elems[i] = NewElem(i)
+		h.Put(elems[i])
+	}
+	var elem IntElem
+	h.Get(&elem)
+	h.Get(&elem)
+	h.DeleteElem(elems[4])
+	h.DeleteElem(NewElem(7))
+
+	stats := h.Stats()
+	if stats.Len != 2 || stats.LookupSize != 2 || stats.Cap < 5 {
+		t.Fatalf("unexpected sizes: %+v", stats)
+	}
+	if stats.PushCount != 5 || stats.PopCount != 2 || stats.DeleteCount != 1 {
+		t.Fatalf("unexpected counters: %+v", stats)
+	}
+	if !stats.IndexerEnabled || stats.DataType != "*heap.IntElem" {
+		t.Fatalf("unexpected type info: %+v", stats)
+	}
+	h.DisableIndexTracking()
+	if h.Stats().IndexerEnabled {
+		t.Fatal("index tracking still reported as enabled")
+	}
+}
+
+func TestMonitorConcurrentWriters(t *testing.T) {
+	s, err := NewSyncHeap(func(a, b *IntElem) bool {
+		return a.data < b.data
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stats := make(chan HeapStats, 1000)
+	s.Monitor(ctx, time.Millisecond, stats)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				s.Put(NewElem(i))
+				if i%2 == 0 {
+					s.Get()
+				}
+				s.Stats()
+			}
+		}()
+	}
+	wg.Wait()
+	cancel()
+
+	final := s.Stats()
+	if final.Len != 1000 || final.PushCount != 2000 || final.PopCount != 1000 {
+		t.Fatalf("unexpected final stats: %+v", final)
+	}
+}
+
+func TestMonitorStopsAndDropsSamples(t *testing.T) {
+	h := NewMinHeap()
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	stats := make(chan HeapStats, 1)
+	h.Monitor(ctx, time.Millisecond, stats)
+
+	// nobody reads, so the monitor must drop samples instead of blocking
+	time.Sleep(20 * time.Millisecond)
+	if len(stats) != 1 {
+		t.Fatalf("expected one buffered sample, got %d", len(stats))
+	}
+	cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatal("monitor goroutine did not exit")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type countingMetrics struct {
+	NoopMetrics
+	pushes, pops int
+}
+
+func (m *countingMetrics) ObservePush() { m.pushes++ }
+func (m *countingMetrics) ObservePop()  { m.pops++ }
+
+func TestStatsCountSwapTopAndMergeAll(t *testing.T) {
+	metrics := &countingMetrics{}
+	h, err := NewHeap(func(a, b *IntElem) bool {
+		return a.data > b.data
+	}, WithMetrics(metrics))
+	if nil != err {
+		t.Fatal(err)
+	}
+	h.Put(NewElem(1))
+	if _, err := h.PopAndPush(NewElem(2)); nil != err {
+		t.Fatal(err)
+	}
+	if stats := h.Stats(); stats.PushCount != 2 || stats.PopCount != 1 {
+		t.Fatalf("after PopAndPush: push=%d pop=%d", stats.PushCount, stats.PopCount)
+	}
+
+	other := NewMaxHeap()
+	other.Put(NewElem(3))
+	other.Put(NewElem(4))
+	if err := h.MergeAll(other); nil != err {
+		t.Fatal(err)
+	}
+	if stats := h.Stats(); stats.PushCount != 4 || stats.Len != 3 {
+		t.Fatalf("after MergeAll: push=%d len=%d", stats.PushCount, stats.Len)
+	}
+	if metrics.pushes != 4 || metrics.pops != 1 {
+		t.Fatalf("metrics saw %d pushes and %d pops", metrics.pushes, metrics.pops)
+	}
+}
+
+func TestElapsedSinceLastPop(t *testing.T) {
+	h := NewMaxHeap()
+	if h.ElapsedSinceLastPop() > time.Second || h.ElapsedSinceLastPush() > time.Second {
+		t.Fatal("a new heap must not look idle")
+	}
+	h.Put(NewElem(1))
+	h.Put(NewElem(2))
+	var elem IntElem
+	h.Get(&elem)
+	before := h.ElapsedSinceLastPop()
+	time.Sleep(5 * time.Millisecond)
+	after := h.ElapsedSinceLastPop()
+	if after <= before {
+		t.Fatalf("elapsed time did not grow: %v then %v", before, after)
+	}
+	h.Get(&elem)
+	if h.ElapsedSinceLastPop() >= after {
+		t.Fatal("pop did not reset the elapsed time")
+	}
+	if h.ElapsedSinceLastPush() < 5*time.Millisecond {
+		t.Fatal("pops must not reset the push timestamp")
+	}
+}
+
+type reading struct {
+	value float64
+}
+
+func (r *reading) Value() float64 { return r.value }
+
+func TestHistogram(t *testing.T) {
+	h := MustHeap(func(a, b *reading) bool {
+		return a.value < b.value
+	})
+	for _, v := range []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 10, 10, 2.4} {
+		h.Put(&reading{v})
+	}
+	counts, err := h.Histogram(5)
+	if nil != err {
+		t.Fatal(err)
+	}
+	// buckets of width 2, the maximum falls into the last one
+	expected := []int{2, 3, 2, 2, 3}
+	sum := 0
+	for index, count := range counts {
+		sum += count
+		if count != expected[index] {
+			t.Fatalf("expected %v, got %v", expected, counts)
+		}
+	}
+	if sum != h.Len() {
+		t.Fatalf("bucket counts sum to %d instead of %d", sum, h.Len())
+	}
+
+	if _, err := h.Histogram(0); nil == err {
+		t.Fatal("expected an error for zero buckets")
+	}
+	if _, err := NewMinHeap().Histogram(3); nil == err {
+		t.Fatal("expected an error for elements without Value")
+	}
+}
+
+func TestBenchmark(t *testing.T) {
+	h := newIntMaxHeap(t, 3, 1, 2)
+	res := h.Benchmark(1000)
+	if res.PushNsPerOp <= 0 || res.PopNsPerOp <= 0 || res.PeekNsPerOp <= 0 {
+		t.Fatalf("expected positive timings, got %+v", res)
+	}
+	if h.Len() != 3 || h.Stats().PushCount != 3 {
+		t.Fatal("Benchmark touched the heap")
+	}
+	if res := h.Benchmark(0); res != (BenchResult{}) {
+		t.Fatalf("expected zero timings for n = 0, got %+v", res)
+	}
+}
+
+func TestBenchmarkSifts(t *testing.T) {
+	unequal := 0
+	h := MustHeap(func(a, b *IntElem) bool {
+		if a.data != b.data {
+			unequal++
+		}
+		return a.data < b.data
+	})
+	for i := 0; i < 100; i++ {
+		h.Put(NewElem(i))
+	}
+	unequal = 0
+	h.Benchmark(1000)
+	if unequal == 0 {
+		t.Fatal("the benchmark only compared equal elements")
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !debug
+
+package heap
+
+func (h *Heap) verifyLess(i, j int) {}
@@ -0,0 +1,164 @@
+package heap
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type Option func(*Heap)
+
+// WithLogger writes a tab separated line per PUT, GET, DELETE and SWAP to w.
+func WithLogger(w io.Writer) Option {
+	return func(h *Heap) {
+		h.logger = w
+	}
+}
+
+// trace writes a line to the logger. Callers check for a logger first, so
+// that heaps without one do not pay for boxing the arguments.
+func (h *Heap) trace(op string, args ...interface{}) {
+	fields := make([]string, 0, len(args)+2)
+	fields = append(fields, op, time.Now().Format(time.RFC3339Nano))
+	for _, arg := range args {
+		fields = append(fields, fmt.Sprintf("%v", arg))
+	}
+	io.WriteString(h.logger, strings.Join(fields, "\t")+"\n")
+}
+
+// WithImmutableComparator calls the comparator twice per comparison and panics
+// with ErrNonDeterministicComparator if the results differ. It is intended for
+// tests and CI runs to catch closures over mutable state.
+func WithImmutableComparator() Option {
+	return func(h *Heap) {
+		h.immutableCmp = true
+	}
+}
+
+// WithoutLookup disables the element to index lookup map to save memory.
+// DeleteElem, Update, Replace, Reschedule, BatchFix, IndexOf, MoveToFront and
+// everything else that finds an element by identity panic with
+// ErrLookupDisabled. TryDeleteElem, TryUpdate and the DeleteElem, Update and
+// Transaction methods of SyncHeap return it instead.
+func WithoutLookup() Option {
+	return func(h *Heap) {
+		h.noLookup = true
+		h.lookup = nil
+	}
+}
+
+// WithStrictTypeCheck additionally rejects comparators operating on pointers
+// to unnamed or unexported interfaces.
+func WithStrictTypeCheck() Option {
+	return func(h *Heap) {
+		h.strictTypes = true
+	}
+}
+
+// WithSeed breaks ties between equal elements by a random rank drawn from a
+// generator seeded with seed, which makes the pop order of equal elements
+// reproducible. Every comparison that returns false costs one extra call of
+// the comparator, so this is meant for tests rather than production use.
+func WithSeed(seed int64) Option {
+	return func(h *Heap) {
+		h.seed = seed
+		h.rng = rand.New(rand.NewSource(seed))
+		h.ties = make(map[reflect.Value]uint64)
+	}
+}
+
+// WithInsertionTracking numbers the elements in the order they are put, so
+// that InsertionOrder can list them oldest first and PopOldest can serve equal
+// elements first in, first out. It costs a map entry per element and a map
+// write on every push and pop.
+func WithInsertionTracking() Option {
+	return func(h *Heap) {
+		h.seqs = make(map[reflect.Value]uint64)
+	}
+}
+
+// WithCompareTimeout makes Less panic with ErrComparatorTimeout if the
+// comparator does not return within d. Every comparison then runs in its own
+// goroutine, which adds considerable overhead to each Less call, and a timed
+// out comparator keeps running in the background until it returns. This is a
+// safety net for comparators that may block, not for production hot paths.
+func WithCompareTimeout(d time.Duration) Option {
+	return func(h *Heap) {
+		h.compareTimeout = d
+	}
+}
+
+// invoke calls the comparator, enforcing the compare timeout if one is set.
+func (h *Heap) invoke(a, b reflect.Value) bool {
+	if h.compareTimeout <= 0 {
+		return h.cmpFn.Call([]reflect.Value{a, b})[0].Bool()
+	}
+	done := make(chan bool, 1)
+	go func() {
+		done <- h.cmpFn.Call([]reflect.Value{a, b})[0].Bool()
+	}()
+	timer := time.NewTimer(h.compareTimeout)
+	defer timer.Stop()
+	select {
+	case ret := <-done:
+		return ret
+	case <-timer.C:
+		panic(fmt.Errorf("%w after %v", ErrComparatorTimeout, h.compareTimeout))
+	}
+}
+
+type EventBus interface {
+	Publish(event string, payload interface{})
+}
+
+// WithEventBus publishes "heap.push", "heap.pop" and "heap.delete" events
+// with the affected element as payload.
+func WithEventBus(bus EventBus) Option {
+	return func(h *Heap) {
+		h.bus = bus
+	}
+}
+
+func (h *Heap) publish(event string, payload interface{}) {
+	if nil == h.bus {
+		return
+	}
+	h.bus.Publish(event, payload)
+}
+
+// Metrics receives counters for heap operations, e.g. to feed Prometheus:
+//
+//	type promMetrics struct {
+//		pushes, pops prometheus.Counter
+//		deletes      *prometheus.CounterVec
+//		size         prometheus.Gauge
+//	}
+//
+//	func (m promMetrics) ObservePush()             { m.pushes.Inc() }
+//	func (m promMetrics) ObservePop()              { m.pops.Inc() }
+//	func (m promMetrics) ObserveDelete(found bool) { m.deletes.WithLabelValues(strconv.FormatBool(found)).Inc() }
+//	func (m promMetrics) ObserveLen(n int)         { m.size.Set(float64(n)) }
+type Metrics interface {
+	ObservePush()
+	ObservePop()
+	ObserveDelete(found bool)
+	ObserveLen(n int)
+}
+
+type NoopMetrics struct{}
+
+func (NoopMetrics) ObservePush()             {}
+func (NoopMetrics) ObservePop()              {}
+func (NoopMetrics) ObserveDelete(found bool) {}
+func (NoopMetrics) ObserveLen(n int)         {}
+
+// WithMetrics reports every Put, Get and DeleteElem as well as the resulting
+// length to m.
+func WithMetrics(m Metrics) Option {
+	return func(h *Heap) {
+		h.metrics = m
+	}
+}
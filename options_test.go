@@ -0,0 +1,373 @@
+package heap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// seededPopOrder pushes eight equal elements into a WithSeed heap and
+// returns their pop order. meddle runs after the first four pushes.
+func seededPopOrder(t *testing.T, meddle func(h *Heap)) []int {
+	h, err := NewHeap(func(a, b *IntElem) bool {
+		return a.data < b.data
+	}, WithSeed(7))
+	if nil != err {
+		t.Fatal(err)
+	}
+	ids := make(map[*IntElem]int)
+	for i := 0; i < 8; i++ {
+		if i == 4 {
+			meddle(h)
+		}
+		elem := NewElem(1)
+		ids[elem] = i
+		h.Put(elem)
+	}
+	var order []int
+	for h.Len() > 0 {
+		order = append(order, ids[h.pop().(*IntElem)])
+	}
+	return order
+}
+
+func TestWithSeedIsReproducible(t *testing.T) {
+	nothing := func(*Heap) {}
+	first, second := seededPopOrder(t, nothing), seededPopOrder(t, nothing)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("same seed gave %v and %v", first, second)
+	}
+}
+
+func TestWithSeedUnaffectedByDerivedHeaps(t *testing.T) {
+	want := seededPopOrder(t, func(*Heap) {})
+	got := seededPopOrder(t, func(h *Heap) {
+		if _, err := h.Prioritize([]interface{}{NewElem(1), NewElem(1), NewElem(1)}); nil != err {
+			t.Fatal(err)
+		}
+		h.IterSorted(func(interface{}, int) bool { return true })
+		h.Copy()
+		h.Invert()
+		h.SplitAt(2)
+	})
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("read only calls changed the pop order from %v to %v", want, got)
+	}
+}
+
+func TestWithSeedReplaceForgetsTieRank(t *testing.T) {
+	h, err := NewHeap(func(a, b *IntElem) bool {
+		return a.data < b.data
+	}, WithSeed(5))
+	if nil != err {
+		t.Fatal(err)
+	}
+	for i := 0; i < 8; i++ {
+		h.Put(NewElem(1))
+	}
+	for i := 0; i < 20; i++ {
+		h.Replace(h.objects[i%8].Interface(), NewElem(1))
+	}
+	if len(h.ties) > h.Len() {
+		t.Fatalf("%d tie ranks kept for %d elements", len(h.ties), h.Len())
+	}
+	for obj := range h.ties {
+		if _, ok := h.lookup[obj]; !ok {
+			t.Fatalf("tie rank kept for replaced element %v", obj.Interface())
+		}
+	}
+}
+
+func TestWithSeedClonesUsableConcurrently(t *testing.T) {
+	h, err := NewHeap(func(a, b *IntElem) bool {
+		return a.data < b.data
+	}, WithSeed(3))
+	if nil != err {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		h.Put(NewElem(i % 3))
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		h.TeeOff(func(c *Heap) {
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					c.Put(NewElem(j % 3))
+				}
+			}()
+		})
+	}
+	wg.Wait()
+}
+
+func TestWithoutLookupRefusesIdentityOperations(t *testing.T) {
+	h, err := NewHeap(func(a, b *IntElem) bool {
+		return a.data < b.data
+	}, WithoutLookup())
+	if nil != err {
+		t.Fatal(err)
+	}
+	item, other := NewElem(1), NewElem(2)
+	h.Put(item)
+
+	operations := map[string]func(){
+		"DeleteElem":   func() { h.DeleteElem(item) },
+		"Update":       func() { h.Update(item) },
+		"Replace":      func() { h.Replace(item, other) },
+		"Reschedule":   func() { h.Reschedule(item, func(interface{}) {}) },
+		"BatchFix":     func() { h.BatchFix(item) },
+		"IndexOf":      func() { h.IndexOf(item) },
+		"MoveToFront":  func() { h.MoveToFront(item) },
+		"RebalanceOne": func() { h.RebalanceOne(item) },
+	}
+	for name, operation := range operations {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != ErrLookupDisabled {
+					t.Fatalf("expected a panic with ErrLookupDisabled, got %v", r)
+				}
+			}()
+			operation()
+		})
+	}
+
+	if err := h.TryDeleteElem(item); !errors.Is(err, ErrLookupDisabled) {
+		t.Fatalf("TryDeleteElem: expected ErrLookupDisabled, got %v", err)
+	}
+	if err := h.TryUpdate(item); !errors.Is(err, ErrLookupDisabled) {
+		t.Fatalf("TryUpdate: expected ErrLookupDisabled, got %v", err)
+	}
+	if h.Len() != 1 {
+		t.Fatalf("expected the element to stay in the heap, got %d elements", h.Len())
+	}
+}
+
+func BenchmarkPushWithoutLookup(b *testing.B) {
+	for _, lookup := range []bool{true, false} {
+		b.Run(fmt.Sprintf("lookup=%v", lookup), func(b *testing.B) {
+			var opts []Option
+			if !lookup {
+				opts = append(opts, WithoutLookup())
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				h, _ := NewHeap(lessIntElem, opts...)
+				for v := 0; v < 1024; v++ {
+					h.Put(NewElem(v * 7919 % 1024))
+				}
+			}
+		})
+	}
+}
+
+func TestTryUpdate(t *testing.T) {
+	h := newIntMaxHeap(t, 3, 5)
+	item := NewElem(1)
+	if err := h.TryUpdate(item); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := h.TryUpdate(1); !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+	h.Put(item)
+	item.data = 9
+	if err := h.TryUpdate(item); nil != err {
+		t.Fatal(err)
+	}
+	if got := drainInts(h); !reflect.DeepEqual(got, []int{9, 5, 3}) {
+		t.Fatalf("unexpected order %v", got)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var log bytes.Buffer
+	h := MustHeap(func(a, b *IntElem) bool {
+		return a.data < b.data
+	}, WithLogger(&log))
+	three, one := NewElem(3), NewElem(1)
+	h.Put(three)
+	h.Put(one)
+	var top IntElem
+	h.Get(&top)
+	h.DeleteElem(one)
+	h.DeleteElem(three)
+
+	counts := make(map[string]int)
+	var deletes []string
+	for _, line := range strings.Split(strings.TrimSuffix(log.String(), "\n"), "\n") {
+		fields := strings.Split(line, "\t")
+		counts[fields[0]]++
+		if fields[0] == "DELETE" {
+			deletes = append(deletes, fields[len(fields)-1])
+		}
+	}
+	// one swap sifts 1 up on its put, one moves the top out on the get
+	want := map[string]int{"PUT": 2, "SWAP": 2, "GET": 1, "DELETE": 2}
+	if !reflect.DeepEqual(counts, want) {
+		t.Fatalf("expected %v log entries, got %v", want, counts)
+	}
+	if !reflect.DeepEqual(deletes, []string{"false", "true"}) {
+		t.Fatalf("expected a failed and a successful delete, got %v", deletes)
+	}
+}
+
+func TestSwapWithoutLoggerDoesNotAllocate(t *testing.T) {
+	h := NewMinHeap()
+	for i := 0; i < 1000; i++ {
+		h.Put(NewElem(i))
+	}
+	// indices above 255 are not served from the runtime's preallocated values
+	if allocs := testing.AllocsPerRun(100, func() { h.Swap(500, 999) }); allocs != 0 {
+		t.Fatalf("expected Swap to not allocate, got %v allocations", allocs)
+	}
+}
+
+type recordingBus struct {
+	events []string
+}
+
+func (b *recordingBus) Publish(event string, payload interface{}) {
+	b.events = append(b.events, fmt.Sprintf("%s %d", event, payload.(*IntElem).data))
+}
+
+func TestWithEventBus(t *testing.T) {
+	bus := &recordingBus{}
+	h := MustHeap(func(a, b *IntElem) bool {
+		return a.data < b.data
+	}, WithEventBus(bus))
+	three, five := NewElem(3), NewElem(5)
+	h.Put(five)
+	h.Put(three)
+	h.Put(NewElem(4))
+	var top IntElem
+	h.Get(&top)
+	h.DeleteElem(five)
+	h.DeleteElem(three)
+
+	want := []string{"heap.push 5", "heap.push 3", "heap.push 4", "heap.pop 3", "heap.delete 5"}
+	if !reflect.DeepEqual(bus.events, want) {
+		t.Fatalf("expected %v, got %v", want, bus.events)
+	}
+}
+
+func TestWithImmutableComparator(t *testing.T) {
+	flip := false
+	h := MustHeap(func(a, b *IntElem) bool {
+		flip = !flip
+		return flip
+	}, WithImmutableComparator())
+	a, b := reflect.ValueOf(NewElem(1)), reflect.ValueOf(NewElem(2))
+	defer func() {
+		if r := recover(); r != ErrNonDeterministicComparator {
+			t.Fatalf("expected a panic with ErrNonDeterministicComparator, got %v", r)
+		}
+	}()
+	// compare directly, Less would hit verifyLess first in debug builds
+	h.compare(a, b)
+}
+
+func TestWithImmutableComparatorStable(t *testing.T) {
+	h := MustHeap(func(a, b *IntElem) bool {
+		return a.data < b.data
+	}, WithImmutableComparator())
+	for _, v := range []int{4, 2, 3, 1} {
+		h.Put(NewElem(v))
+	}
+	if got := drainInts(h); !reflect.DeepEqual(got, []int{1, 2, 3, 4}) {
+		t.Fatalf("unexpected order %v", got)
+	}
+}
+
+type recordingMetrics struct {
+	pushes, pops    int
+	found, notFound int
+	lens            []int
+}
+
+func (m *recordingMetrics) ObservePush() { m.pushes++ }
+func (m *recordingMetrics) ObservePop()  { m.pops++ }
+func (m *recordingMetrics) ObserveLen(n int) {
+	m.lens = append(m.lens, n)
+}
+func (m *recordingMetrics) ObserveDelete(found bool) {
+	if found {
+		m.found++
+	} else {
+		m.notFound++
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+	h := MustHeap(func(a, b *IntElem) bool {
+		return a.data < b.data
+	}, WithMetrics(metrics))
+	one, two := NewElem(1), NewElem(2)
+	h.Put(one)
+	h.Put(two)
+	h.Put(NewElem(3))
+	var top IntElem
+	h.Get(&top)
+	h.DeleteElem(one)
+	h.DeleteElem(two)
+
+	if metrics.pushes != 3 || metrics.pops != 1 || metrics.found != 1 || metrics.notFound != 1 {
+		t.Fatalf("expected 3 pushes, 1 pop and 1 of 2 deletes found, got %+v", *metrics)
+	}
+	if want := []int{1, 2, 3, 2, 2, 1}; !reflect.DeepEqual(metrics.lens, want) {
+		t.Fatalf("expected lengths %v, got %v", want, metrics.lens)
+	}
+}
+
+type hiddenInterface interface {
+	hidden()
+}
+
+func TestInterfaceElementTypes(t *testing.T) {
+	never := func(a, b *interface{}) bool { return false }
+	if _, err := NewHeap(never); err != ErrEmptyInterface {
+		t.Fatalf("expected ErrEmptyInterface, got %v", err)
+	}
+	hidden := func(a, b *hiddenInterface) bool { return false }
+	if _, err := NewHeap(hidden); nil != err {
+		t.Fatalf("expected unexported interfaces to pass without strict checks, got %v", err)
+	}
+	if _, err := NewHeap(hidden, WithStrictTypeCheck()); nil == err {
+		t.Fatal("expected WithStrictTypeCheck to reject an unexported interface")
+	}
+	if _, err := NewHeap(func(a, b *io.Reader) bool { return false }, WithStrictTypeCheck()); nil != err {
+		t.Fatalf("expected an exported interface to pass, got %v", err)
+	}
+}
+
+func TestWithCompareTimeout(t *testing.T) {
+	h, err := NewHeap(func(a, b *IntElem) bool {
+		if a.data < 0 || b.data < 0 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return a.data > b.data
+	}, WithCompareTimeout(50*time.Millisecond))
+	if nil != err {
+		t.Fatal(err)
+	}
+	h.Put(NewElem(1))
+	h.Put(NewElem(2))
+	mustBeHealthy(t, h)
+
+	defer func() {
+		err, ok := recover().(error)
+		if !ok || !errors.Is(err, ErrComparatorTimeout) {
+			t.Fatalf("expected a panic with ErrComparatorTimeout, got %v", err)
+		}
+	}()
+	h.Put(NewElem(-1))
+}
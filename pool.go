@@ -0,0 +1,34 @@
+package heap
+
+import (
+	"sync"
+)
+
+// HeapPool recycles heaps sharing the same comparator.
+type HeapPool struct {
+	pool sync.Pool
+}
+
+// NewHeapPool panics if compareFn is not a valid comparator. poolSize heaps
+// are allocated upfront.
+func NewHeapPool(compareFn interface{}, poolSize int) *HeapPool {
+	MustHeap(compareFn)
+	p := &HeapPool{}
+	p.pool.New = func() interface{} {
+		return MustHeap(compareFn)
+	}
+	for i := 0; i < poolSize; i++ {
+		p.pool.Put(p.pool.New())
+	}
+	return p
+}
+
+func (p *HeapPool) Acquire() *Heap {
+	return p.pool.Get().(*Heap)
+}
+
+// Release resets h and returns it to the pool. h must not be used afterwards.
+func (p *HeapPool) Release(h *Heap) {
+	h.Reset()
+	p.pool.Put(h)
+}
@@ -0,0 +1,52 @@
+package heap
+
+import (
+	"testing"
+)
+
+func lessIntElem(a, b *IntElem) bool {
+	return a.data < b.data
+}
+
+func TestHeapPoolDoesNotLeakElements(t *testing.T) {
+	p := NewHeapPool(lessIntElem, 2)
+	for round := 0; round < 10; round++ {
+		h := p.Acquire()
+		if h.Len() != 0 {
+			t.Fatalf("round %d: acquired a heap holding %d elements", round, h.Len())
+		}
+		for v := 0; v < 5; v++ {
+			h.Put(NewElem(v))
+		}
+		var top IntElem
+		h.Get(&top)
+		p.Release(h)
+	}
+}
+
+func TestNewHeapPoolRejectsInvalidComparator(t *testing.T) {
+	defer func() {
+		if nil == recover() {
+			t.Fatal("expected a panic for an invalid comparator")
+		}
+	}()
+	NewHeapPool(func(a *IntElem) bool { return false }, 1)
+}
+
+func BenchmarkHeapPool(b *testing.B) {
+	p := NewHeapPool(lessIntElem, 1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		h := p.Acquire()
+		h.Put(NewElem(i))
+		p.Release(h)
+	}
+}
+
+func BenchmarkNewHeapWithoutPool(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		h := MustHeap(lessIntElem)
+		h.Put(NewElem(i))
+	}
+}
@@ -0,0 +1,31 @@
+package heap
+
+import (
+	"cmp"
+)
+
+type PriorityFunc[T any] func(a, b T) bool
+
+// Reversed returns a PriorityFunc ordering the opposite way. Equal elements
+// keep comparing as not less.
+func (f PriorityFunc[T]) Reversed() PriorityFunc[T] {
+	return func(a, b T) bool {
+		return f(b, a)
+	}
+}
+
+// Ascending orders plain values from small to large. Since ordered types are
+// never pointers, it is meant for the values of an IndexedPQ; NewHeap and
+// NewSyncHeap reject it with ErrMustBePointerReceiver.
+func Ascending[T cmp.Ordered]() PriorityFunc[T] {
+	return func(a, b T) bool {
+		return a < b
+	}
+}
+
+// Descending is Ascending ordering from large to small.
+func Descending[T cmp.Ordered]() PriorityFunc[T] {
+	return func(a, b T) bool {
+		return a > b
+	}
+}
@@ -0,0 +1,43 @@
+package heap
+
+import (
+	"testing"
+)
+
+func TestPriorityFuncs(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		less PriorityFunc[int]
+		want [3]bool // less(1, 2), less(2, 1), less(2, 2)
+	}{
+		{"Ascending", Ascending[int](), [3]bool{true, false, false}},
+		{"Descending", Descending[int](), [3]bool{false, true, false}},
+		{"Ascending.Reversed", Ascending[int]().Reversed(), [3]bool{false, true, false}},
+		{"Descending.Reversed", Descending[int]().Reversed(), [3]bool{true, false, false}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := [3]bool{test.less(1, 2), test.less(2, 1), test.less(2, 2)}
+			if got != test.want {
+				t.Fatalf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestPriorityFuncOrdersHeap(t *testing.T) {
+	q := NewIndexedPQ[string](Descending[float64]().Reversed())
+	q.Put("b", 2.5)
+	q.Put("a", 1.5)
+	q.Put("c", 3.5)
+	for _, want := range []string{"a", "b", "c"} {
+		if key, _, _ := q.Pop(); key != want {
+			t.Fatalf("expected %s, got %s", want, key)
+		}
+	}
+}
+
+func TestPriorityFuncRejectedByHeaps(t *testing.T) {
+	if _, err := NewSyncHeap(Ascending[int]()); err != ErrMustBePointerReceiver {
+		t.Fatalf("expected ErrMustBePointerReceiver, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package heap
+
+import (
+	coheap "container/heap"
+	"fmt"
+	"reflect"
+)
+
+// HeapSnapshot captures the elements of a heap at one point in time. The
+// elements themselves are shared with the heap, not copied.
+type HeapSnapshot struct {
+	Elements []interface{}
+	DataType string
+}
+
+func (h *Heap) Snapshot() *HeapSnapshot {
+	s := &HeapSnapshot{
+		Elements: make([]interface{}, len(h.objects)),
+		DataType: h.dataType.String(),
+	}
+	for index, obj := range h.objects {
+		s.Elements[index] = obj.Interface()
+	}
+	return s
+}
+
+func RestoreSnapshot(s *HeapSnapshot, cmpFn interface{}) (*Heap, error) {
+	h, err := NewHeap(cmpFn)
+	if nil != err {
+		return nil, err
+	}
+	if h.dataType.String() != s.DataType {
+		return nil, ErrTypeMismatch
+	}
+	for _, item := range s.Elements {
+		if reflect.TypeOf(item) != h.dataType {
+			return nil, ErrTypeMismatch
+		}
+		h.Push(item)
+	}
+	coheap.Init(h)
+	return h, nil
+}
+
+// Flatten maps every backing slice position to its element.
+func (h *Heap) Flatten() map[int]interface{} {
+	m := make(map[int]interface{}, len(h.objects))
+	for index, obj := range h.objects {
+		m[index] = obj.Interface()
+	}
+	return m
+}
+
+// UnflattenFrom replaces the contents of the heap with a map produced by
+// Flatten. The keys must be exactly 0 to len(m)-1.
+func (h *Heap) UnflattenFrom(m map[int]interface{}) error {
+	objects := make([]reflect.Value, len(m))
+	for index := range objects {
+		item, ok := m[index]
+		if !ok {
+			return fmt.Errorf("missing index %d", index)
+		}
+		if reflect.TypeOf(item) != h.dataType {
+			return ErrTypeMismatch
+		}
+		objects[index] = reflect.ValueOf(item)
+	}
+	h.objects = objects
+	h.reindex()
+	coheap.Init(h)
+	h.evict()
+	return nil
+}
@@ -0,0 +1,100 @@
+package heap
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	h := NewMinHeap()
+	for i := 0; i < 50; i++ {
+		h.Put(NewElem(rng.Intn(100)))
+	}
+	s := h.Snapshot()
+	if s.DataType != "*heap.IntElem" || len(s.Elements) != 50 {
+		t.Fatalf("unexpected snapshot of %d %s elements", len(s.Elements), s.DataType)
+	}
+	want := make([]int, len(s.Elements))
+	for index, item := range s.Elements {
+		want[index] = item.(*IntElem).data
+	}
+	sort.Ints(want)
+
+	for i := 0; i < 20; i++ {
+		h.pop()
+		h.Put(NewElem(rng.Intn(100)))
+	}
+
+	restored, err := RestoreSnapshot(s, lessIntElem)
+	if nil != err {
+		t.Fatal(err)
+	}
+	mustBeHealthy(t, restored)
+	for _, item := range s.Elements {
+		if _, ok := restored.IndexOf(item); !ok {
+			t.Fatalf("snapshot element %d is missing from the restored heap", item.(*IntElem).data)
+		}
+	}
+	got := drainInts(restored)
+	for index := range want {
+		if got[index] != want[index] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestRestoreSnapshotTypeMismatch(t *testing.T) {
+	s := NewMinHeap().Snapshot()
+	if _, err := RestoreSnapshot(s, func(a, b *Int64Elem) bool { return a.data < b.data }); err != ErrTypeMismatch {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+}
+
+func TestFlattenJSONRoundTrip(t *testing.T) {
+	byN := func(a, b *gobElem) bool {
+		return a.N < b.N
+	}
+	h := MustHeap(byN)
+	for i := 0; i < 20; i++ {
+		h.Put(&gobElem{N: i * 7 % 20})
+	}
+	encoded, err := json.Marshal(h.Flatten())
+	if nil != err {
+		t.Fatal(err)
+	}
+	var decoded map[int]*gobElem
+	if err := json.Unmarshal(encoded, &decoded); nil != err {
+		t.Fatal(err)
+	}
+	flat := make(map[int]interface{}, len(decoded))
+	for index, item := range decoded {
+		flat[index] = item
+	}
+
+	restored := MustHeap(byN)
+	if err := restored.UnflattenFrom(flat); nil != err {
+		t.Fatal(err)
+	}
+	mustBeHealthy(t, restored)
+	for index, obj := range restored.objects {
+		if got, want := obj.Interface().(*gobElem).N, h.objects[index].Interface().(*gobElem).N; got != want {
+			t.Fatalf("expected %d at index %d, got %d", want, index, got)
+		}
+	}
+}
+
+func TestUnflattenFromRejectsGaps(t *testing.T) {
+	h := newIntMaxHeap(t, 1)
+	if err := h.UnflattenFrom(map[int]interface{}{0: NewElem(1), 2: NewElem(2)}); nil == err {
+		t.Fatal("expected an error for non-contiguous indices")
+	}
+	if err := h.UnflattenFrom(map[int]interface{}{0: NewInt64Elem(1)}); err != ErrTypeMismatch {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+	if h.Len() != 1 {
+		t.Fatalf("expected failed calls to keep the heap intact, got %d elements", h.Len())
+	}
+}
@@ -0,0 +1,74 @@
+package heap
+
+import (
+	coheap "container/heap"
+	"errors"
+	"reflect"
+	"sort"
+)
+
+// SortHeap is a heap on top of a slice implementing sort.Interface. Elements
+// are addressed by index only, so there is no lookup or Indexer support.
+type SortHeap struct {
+	data  sort.Interface
+	slice reflect.Value
+}
+
+// NewSortInterfaceHeap heapifies data in place. data must be a pointer to the
+// slice, e.g. &sort.IntSlice{...}, so that Put and Get can grow and shrink it.
+func NewSortInterfaceHeap(data sort.Interface) (*SortHeap, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return nil, errors.New("data must be a pointer to a slice")
+	}
+	s := &SortHeap{
+		data:  data,
+		slice: v.Elem(),
+	}
+	coheap.Init(s)
+	return s, nil
+}
+
+func (s *SortHeap) Len() int {
+	return s.data.Len()
+}
+
+func (s *SortHeap) Less(i, j int) bool {
+	return s.data.Less(i, j)
+}
+
+func (s *SortHeap) Swap(i, j int) {
+	s.data.Swap(i, j)
+}
+
+func (s *SortHeap) Push(i interface{}) {
+	if reflect.TypeOf(i) != s.slice.Type().Elem() {
+		panic("tried to put invalid type")
+	}
+	s.slice.Set(reflect.Append(s.slice, reflect.ValueOf(i)))
+}
+
+func (s *SortHeap) Pop() interface{} {
+	length := s.slice.Len()
+	ret := s.slice.Index(length - 1).Interface()
+	s.slice.Set(s.slice.Slice(0, length-1))
+	return ret
+}
+
+func (s *SortHeap) Put(i interface{}) {
+	coheap.Push(s, i)
+}
+
+func (s *SortHeap) Get() (interface{}, bool) {
+	if s.Len() == 0 {
+		return nil, false
+	}
+	return coheap.Pop(s), true
+}
+
+func (s *SortHeap) Peek() (interface{}, bool) {
+	if s.Len() == 0 {
+		return nil, false
+	}
+	return s.slice.Index(0).Interface(), true
+}
@@ -0,0 +1,43 @@
+package heap
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSortInterfaceHeap(t *testing.T) {
+	data := &sort.IntSlice{5, 2, 8, 1}
+	s, err := NewSortInterfaceHeap(data)
+	if nil != err {
+		t.Fatal(err)
+	}
+	s.Put(7)
+	s.Put(0)
+	if top, ok := s.Peek(); !ok || top != 0 {
+		t.Fatalf("expected 0 on top, got %v", top)
+	}
+	var got []int
+	for {
+		item, ok := s.Get()
+		if !ok {
+			break
+		}
+		got = append(got, item.(int))
+	}
+	if want := []int{0, 1, 2, 5, 7, 8}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if data.Len() != 0 {
+		t.Fatalf("expected the underlying slice to shrink to 0, got %d", data.Len())
+	}
+	if _, ok := s.Peek(); ok {
+		t.Fatal("expected Peek on an empty heap to return false")
+	}
+}
+
+func TestSortInterfaceHeapNeedsSlicePointer(t *testing.T) {
+	if _, err := NewSortInterfaceHeap(sort.IntSlice{1}); nil == err {
+		t.Fatal("expected an error for a slice passed by value")
+	}
+}
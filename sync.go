@@ -0,0 +1,266 @@
+package heap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// SyncHeap is a typed, mutex guarded wrapper around Heap. T is the pointer
+// type the comparator operates on.
+type SyncHeap[T any] struct {
+	mu    sync.Mutex
+	inner *Heap
+
+	// ready is closed and replaced whenever an element is put.
+	ready chan struct{}
+
+	// ctx is the optional heap level context set by NewContextHeap.
+	ctx context.Context
+}
+
+func NewSyncHeap[T any](compareFn PriorityFunc[T], opts ...Option) (*SyncHeap[T], error) {
+	h, err := NewHeap(compareFn, opts...)
+	if nil != err {
+		return nil, err
+	}
+	return &SyncHeap[T]{
+		inner: h,
+		ready: make(chan struct{}),
+	}, nil
+}
+
+// NewContextHeap creates a SyncHeap bound to ctx. Once ctx is done, all
+// blocked and future WaitGet calls return ctx.Err().
+func NewContextHeap[T any](ctx context.Context, compareFn PriorityFunc[T], opts ...Option) (*SyncHeap[T], error) {
+	s, err := NewSyncHeap(compareFn, opts...)
+	if nil != err {
+		return nil, err
+	}
+	s.ctx = ctx
+	return s, nil
+}
+
+// done returns the heap level done channel, or nil if there is none.
+func (s *SyncHeap[T]) done() <-chan struct{} {
+	if nil == s.ctx {
+		return nil
+	}
+	return s.ctx.Done()
+}
+
+func (s *SyncHeap[T]) Put(item T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inner.Put(item)
+	close(s.ready)
+	s.ready = make(chan struct{})
+}
+
+func (s *SyncHeap[T]) Get() (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var zero T
+	if s.inner.Len() == 0 {
+		return zero, false
+	}
+	return s.inner.pop().(T), true
+}
+
+// WaitGet blocks until an element is available or ctx is done.
+func (s *SyncHeap[T]) WaitGet(ctx context.Context) (T, error) {
+	var zero T
+	for {
+		if nil != s.ctx && nil != s.ctx.Err() {
+			return zero, s.ctx.Err()
+		}
+		s.mu.Lock()
+		if s.inner.Len() > 0 {
+			item := s.inner.pop().(T)
+			s.mu.Unlock()
+			return item, nil
+		}
+		ready := s.ready
+		s.mu.Unlock()
+
+		select {
+		case <-ready:
+		case <-s.done():
+			return zero, s.ctx.Err()
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// TimeGet is WaitGet with a timeout, returning ErrTimeout once it expires.
+func (s *SyncHeap[T]) TimeGet(timeout time.Duration) (T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	item, err := s.WaitGet(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return item, ErrTimeout
+	}
+	return item, err
+}
+
+func (s *SyncHeap[T]) Peek() (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var zero T
+	if s.inner.Len() == 0 {
+		return zero, false
+	}
+	return s.inner.objects[0].Interface().(T), true
+}
+
+// DeleteElem removes item. Like Transaction it returns ErrNotFound if item is
+// not in the heap and ErrLookupDisabled on a heap built with WithoutLookup.
+func (s *SyncHeap[T]) DeleteElem(item T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inner.TryDeleteElem(item)
+}
+
+// Update restores the position of item after its priority changed. It
+// returns the same errors as DeleteElem.
+func (s *SyncHeap[T]) Update(item T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inner.TryUpdate(item)
+}
+
+// Len reads the atomic length of the underlying heap and does not take the
+// lock.
+func (s *SyncHeap[T]) Len() int {
+	return s.inner.Len()
+}
+
+// Stats reports the statistics of the underlying heap. Like Heap.Stats it
+// does not take the lock.
+func (s *SyncHeap[T]) Stats() HeapStats {
+	return s.inner.Stats()
+}
+
+// Monitor is Heap.Monitor for the underlying heap.
+func (s *SyncHeap[T]) Monitor(ctx context.Context, interval time.Duration, stats chan<- HeapStats) {
+	s.inner.Monitor(ctx, interval, stats)
+}
+
+// TimedHeap is a SyncHeap whose Get blocks for at most a fixed timeout. Put,
+// Peek and DeleteElem never block and are used as is.
+type TimedHeap[T any] struct {
+	*SyncHeap[T]
+	timeout time.Duration
+}
+
+// WithTimeout returns a TimedHeap sharing the elements of s.
+func (s *SyncHeap[T]) WithTimeout(d time.Duration) *TimedHeap[T] {
+	return &TimedHeap[T]{SyncHeap: s, timeout: d}
+}
+
+// Get waits for an element, returning ErrTimeout once the timeout expires.
+func (t *TimedHeap[T]) Get() (T, error) {
+	return t.TimeGet(t.timeout)
+}
+
+type txKind int
+
+const (
+	txPut txKind = iota
+	txDelete
+	txUpdate
+)
+
+type txOp[T any] struct {
+	kind txKind
+	item T
+}
+
+// HeapTx buffers the operations of a transaction, see SyncHeap.Transaction.
+type HeapTx[T any] struct {
+	ops []txOp[T]
+}
+
+func (tx *HeapTx[T]) Put(item T) {
+	tx.ops = append(tx.ops, txOp[T]{txPut, item})
+}
+
+func (tx *HeapTx[T]) DeleteElem(item T) {
+	tx.ops = append(tx.ops, txOp[T]{txDelete, item})
+}
+
+func (tx *HeapTx[T]) Update(item T) {
+	tx.ops = append(tx.ops, txOp[T]{txUpdate, item})
+}
+
+// Transaction lets fn buffer operations on a HeapTx and applies all of them
+// under a single lock once fn returns nil. If fn returns an error, or an
+// element to delete or update is not in the heap at that point, nothing is
+// changed. fn itself runs without holding the lock. On a heap built with
+// WithoutLookup deletes and updates fail with ErrLookupDisabled. On a bounded
+// heap a put may evict any element, so a delete or update following a put
+// in the same transaction fails with ErrEvictingTransaction.
+func (s *SyncHeap[T]) Transaction(fn func(*HeapTx[T]) error) error {
+	tx := &HeapTx[T]{}
+	if err := fn(tx); nil != err {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inner.noLookup {
+		for _, op := range tx.ops {
+			if op.kind != txPut {
+				return ErrLookupDisabled
+			}
+		}
+	}
+	present := make(map[reflect.Value]bool)
+	contains := func(v reflect.Value) bool {
+		if in, ok := present[v]; ok {
+			return in
+		}
+		_, ok := s.inner.lookup[v]
+		return ok
+	}
+	put := false
+	for _, op := range tx.ops {
+		v := reflect.ValueOf(op.item)
+		switch op.kind {
+		case txPut:
+			present[v] = true
+			put = true
+		case txDelete, txUpdate:
+			if put && s.inner.maxSize > 0 {
+				return ErrEvictingTransaction
+			}
+			if !contains(v) {
+				return fmt.Errorf("%w: %v", ErrNotFound, op.item)
+			}
+			if op.kind == txDelete {
+				present[v] = false
+			}
+		}
+	}
+
+	// the checks above guarantee that every delete and update finds its element
+	for _, op := range tx.ops {
+		switch op.kind {
+		case txPut:
+			s.inner.Put(op.item)
+		case txDelete:
+			s.inner.DeleteElem(op.item)
+		case txUpdate:
+			s.inner.Update(op.item)
+		}
+	}
+	if put {
+		close(s.ready)
+		s.ready = make(chan struct{})
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+package heap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newIntSyncHeap(t *testing.T) *SyncHeap[*IntElem] {
+	t.Helper()
+	s, err := NewSyncHeap(func(a, b *IntElem) bool {
+		return a.data > b.data
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestLenConsistency(t *testing.T) {
+	const workers, rounds = 8, 1000
+	s := newIntSyncHeap(t)
+	var done atomic.Bool
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for !done.Load() {
+				// every worker holds at most one element at a time
+				if n := s.Len(); n < 0 || n > workers {
+					t.Errorf("Len returned %d", n)
+					return
+				}
+			}
+		}()
+	}
+
+	var writers sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		writers.Add(1)
+		go func(w int) {
+			defer writers.Done()
+			for i := 0; i < rounds; i++ {
+				s.Put(NewElem(w*rounds + i))
+				if _, ok := s.Get(); !ok {
+					t.Error("Get found no element")
+					return
+				}
+			}
+		}(w)
+	}
+	writers.Wait()
+	done.Store(true)
+	readers.Wait()
+
+	if n := s.Len(); n != 0 {
+		t.Fatalf("expected an empty heap, got Len %d", n)
+	}
+}
+
+func TestTransactionWithoutLookup(t *testing.T) {
+	s, err := NewSyncHeap(func(a, b *IntElem) bool {
+		return a.data > b.data
+	}, WithoutLookup())
+	if nil != err {
+		t.Fatal(err)
+	}
+	item := NewElem(1)
+	if err := s.Transaction(func(tx *HeapTx[*IntElem]) error {
+		tx.Put(item)
+		return nil
+	}); nil != err {
+		t.Fatal(err)
+	}
+	err = s.Transaction(func(tx *HeapTx[*IntElem]) error {
+		tx.Put(NewElem(2))
+		tx.DeleteElem(item)
+		return nil
+	})
+	if !errors.Is(err, ErrLookupDisabled) {
+		t.Fatalf("expected ErrLookupDisabled, got %v", err)
+	}
+	if err := s.DeleteElem(item); !errors.Is(err, ErrLookupDisabled) {
+		t.Fatalf("DeleteElem: expected ErrLookupDisabled, got %v", err)
+	}
+	if err := s.Update(item); !errors.Is(err, ErrLookupDisabled) {
+		t.Fatalf("Update: expected ErrLookupDisabled, got %v", err)
+	}
+	if s.Len() != 1 {
+		t.Fatalf("expected the failed transaction to change nothing, got %d elements", s.Len())
+	}
+}
+
+func TestSyncHeapStress(t *testing.T) {
+	const workers, rounds = 8, 500
+	s := newIntSyncHeap(t)
+	var removed atomic.Int64
+	t.Run("workers", func(t *testing.T) {
+		for w := 0; w < workers; w++ {
+			w := w
+			t.Run(fmt.Sprint(w), func(t *testing.T) {
+				t.Parallel()
+				for i := 0; i < rounds; i++ {
+					item := NewElem(w*rounds + i)
+					s.Put(item)
+					s.Peek()
+					s.Update(item)
+					if i%3 == 0 && nil == s.DeleteElem(item) {
+						removed.Add(1)
+					}
+					if i%2 == 0 {
+						if _, ok := s.Get(); ok {
+							removed.Add(1)
+						}
+					}
+					s.Len()
+				}
+			})
+		}
+	})
+
+	if want := workers*rounds - int(removed.Load()); s.Len() != want {
+		t.Fatalf("expected %d elements, got %d", want, s.Len())
+	}
+	mustBeHealthy(t, s.inner)
+	last, _ := s.Get()
+	for s.Len() > 0 {
+		item, _ := s.Get()
+		if item.data > last.data {
+			t.Fatalf("%d popped after %d", item.data, last.data)
+		}
+		last = item
+	}
+}
+
+func TestTimeGet(t *testing.T) {
+	s := newIntSyncHeap(t)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		s.Put(NewElem(1))
+	}()
+	start := time.Now()
+	if _, err := s.TimeGet(20 * time.Millisecond); err != ErrTimeout {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed > 150*time.Millisecond {
+		t.Fatalf("expected to give up after about 20ms, took %v", elapsed)
+	}
+
+	item, err := s.TimeGet(5 * time.Second)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if item.data != 1 {
+		t.Fatalf("expected the produced element, got %d", item.data)
+	}
+}
+
+func TestContextHeapCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := NewContextHeap(ctx, func(a, b *IntElem) bool {
+		return a.data < b.data
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	const waiters = 5
+	errs := make(chan error, waiters)
+	for i := 0; i < waiters; i++ {
+		go func() {
+			_, err := s.WaitGet(context.Background())
+			errs <- err
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	for i := 0; i < waiters; i++ {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected context.Canceled, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected every blocked WaitGet to return after the cancellation")
+		}
+	}
+	s.Put(NewElem(1))
+	if _, err := s.WaitGet(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected later calls to fail with context.Canceled, got %v", err)
+	}
+}
+
+func TestWaitGetCallerContext(t *testing.T) {
+	s := newIntSyncHeap(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.WaitGet(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	go s.Put(NewElem(3))
+	item, err := s.WaitGet(context.Background())
+	if nil != err || item.data != 3 {
+		t.Fatalf("expected 3, got %v and %v", item, err)
+	}
+}
+
+func TestTimedHeap(t *testing.T) {
+	timed := newIntSyncHeap(t).WithTimeout(30 * time.Millisecond)
+	start := time.Now()
+	if _, err := timed.Get(); err != ErrTimeout {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("expected Get to wait for the timeout, returned after %v", elapsed)
+	}
+	timed.Put(NewElem(2))
+	if item, err := timed.Get(); nil != err || item.data != 2 {
+		t.Fatalf("expected 2, got %v and %v", item, err)
+	}
+}
+
+func TestTransactionAtomic(t *testing.T) {
+	const workers, perWorker = 4, 64
+	s := newIntSyncHeap(t)
+	sum := func() int {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		total := 0
+		for _, obj := range s.inner.objects {
+			total += obj.Interface().(*IntElem).data
+		}
+		return total
+	}
+	owned := make([][]*IntElem, workers)
+	for w := range owned {
+		for i := 0; i < perWorker; i++ {
+			item := NewElem(1)
+			owned[w] = append(owned[w], item)
+			s.Put(item)
+		}
+	}
+
+	// every transaction merges two elements into one, keeping the sum
+	stop := make(chan struct{})
+	observed := make(chan error, 1)
+	go func() {
+		defer close(observed)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if got := sum(); got != workers*perWorker {
+				observed <- fmt.Errorf("observed a partial transaction, sum %d", got)
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := range owned {
+		wg.Add(1)
+		go func(items []*IntElem) {
+			defer wg.Done()
+			for len(items) > 1 {
+				a, b := items[0], items[1]
+				merged := NewElem(a.data + b.data)
+				if err := s.Transaction(func(tx *HeapTx[*IntElem]) error {
+					tx.DeleteElem(a)
+					tx.DeleteElem(b)
+					tx.Put(merged)
+					return nil
+				}); nil != err {
+					t.Error(err)
+					return
+				}
+				items = append(items[2:], merged)
+			}
+		}(owned[w])
+	}
+	wg.Wait()
+	close(stop)
+	if err := <-observed; nil != err {
+		t.Fatal(err)
+	}
+	if s.Len() != workers || sum() != workers*perWorker {
+		t.Fatalf("expected %d elements summing to %d, got %d summing to %d", workers, workers*perWorker, s.Len(), sum())
+	}
+	mustBeHealthy(t, s.inner)
+}
+
+func TestTransactionRollback(t *testing.T) {
+	s := newIntSyncHeap(t)
+	a, b := NewElem(1), NewElem(2)
+	s.Put(a)
+	s.Put(b)
+
+	failed := errors.New("abort")
+	if err := s.Transaction(func(tx *HeapTx[*IntElem]) error {
+		tx.DeleteElem(a)
+		tx.Put(NewElem(3))
+		return failed
+	}); err != failed {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+	err := s.Transaction(func(tx *HeapTx[*IntElem]) error {
+		tx.DeleteElem(a)
+		tx.Put(NewElem(3))
+		tx.DeleteElem(NewElem(4))
+		return nil
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	err = s.Transaction(func(tx *HeapTx[*IntElem]) error {
+		tx.DeleteElem(b)
+		tx.Update(b)
+		return nil
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for an update after a delete, got %v", err)
+	}
+
+	if s.Len() != 2 {
+		t.Fatalf("expected the failed transactions to change nothing, got %d elements", s.Len())
+	}
+	if top, _ := s.Peek(); top != b {
+		t.Fatalf("expected 2 on top, got %d", top.data)
+	}
+	mustBeHealthy(t, s.inner)
+}
+
+func TestTransactionBoundedHeap(t *testing.T) {
+	s := newIntSyncHeap(t)
+	s.inner.maxSize = 2
+	small, large := NewElem(1), NewElem(5)
+	s.Put(large)
+	s.Put(small)
+
+	// the put evicts small before the delete could remove it
+	err := s.Transaction(func(tx *HeapTx[*IntElem]) error {
+		tx.Put(NewElem(9))
+		tx.DeleteElem(small)
+		return nil
+	})
+	if !errors.Is(err, ErrEvictingTransaction) {
+		t.Fatalf("expected ErrEvictingTransaction, got %v", err)
+	}
+	if s.Len() != 2 {
+		t.Fatalf("expected the failed transaction to change nothing, got %d elements", s.Len())
+	}
+
+	if err := s.Transaction(func(tx *HeapTx[*IntElem]) error {
+		tx.DeleteElem(small)
+		tx.Put(NewElem(9))
+		tx.Put(NewElem(7))
+		return nil
+	}); nil != err {
+		t.Fatal(err)
+	}
+	if got, want := drainInts(s.inner), []int{9, 7}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
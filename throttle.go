@@ -0,0 +1,111 @@
+package heap
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ThrottledHeap is a SyncHeap whose Get is rate limited by a token bucket.
+type ThrottledHeap[T any] struct {
+	*SyncHeap[T]
+
+	mu     sync.Mutex
+	tokens int
+	// avail is closed and replaced whenever tokens are granted.
+	avail chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Throttle returns a ThrottledHeap sharing the elements of s that hands out
+// rate elements per second. At most one token is kept while idle, more can
+// be granted with Burst. Stop must be called to release the ticker.
+func (s *SyncHeap[T]) Throttle(rate float64) *ThrottledHeap[T] {
+	if rate <= 0 {
+		panic("rate must be positive")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &ThrottledHeap[T]{
+		SyncHeap: s,
+		avail:    make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go t.refill(time.Duration(float64(time.Second) / rate))
+	return t
+}
+
+func (t *ThrottledHeap[T]) refill(interval time.Duration) {
+	defer close(t.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.mu.Lock()
+			if t.tokens < 1 {
+				t.grant(1)
+			}
+			t.mu.Unlock()
+		case <-t.ctx.Done():
+			return
+		}
+	}
+}
+
+// grant must be called with mu held.
+func (t *ThrottledHeap[T]) grant(n int) {
+	t.tokens += n
+	close(t.avail)
+	t.avail = make(chan struct{})
+}
+
+// Burst immediately grants n extra tokens.
+func (t *ThrottledHeap[T]) Burst(n int) {
+	if n <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.grant(n)
+}
+
+// Get waits for a token and then for an element. After Stop it returns
+// ErrStopped.
+func (t *ThrottledHeap[T]) Get() (T, error) {
+	var zero T
+	for {
+		t.mu.Lock()
+		if nil != t.ctx.Err() {
+			t.mu.Unlock()
+			return zero, ErrStopped
+		}
+		if t.tokens > 0 {
+			t.tokens--
+			t.mu.Unlock()
+			break
+		}
+		avail := t.avail
+		t.mu.Unlock()
+
+		select {
+		case <-avail:
+		case <-t.ctx.Done():
+		}
+	}
+	item, err := t.WaitGet(t.ctx)
+	if nil != err {
+		return zero, ErrStopped
+	}
+	return item, nil
+}
+
+// Stop shuts down the token bucket and waits for its goroutine to exit.
+func (t *ThrottledHeap[T]) Stop() {
+	t.cancel()
+	<-t.done
+}
@@ -0,0 +1,70 @@
+package heap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	const rate, burst = 20, 5
+	s := newIntSyncHeap(t)
+	for i := 0; i < 1000; i++ {
+		s.Put(NewElem(i))
+	}
+	throttled := s.Throttle(rate)
+	throttled.Burst(burst)
+
+	start := time.Now()
+	got := make(chan time.Time, 1000)
+	stopped := make(chan error, 1)
+	go func() {
+		for {
+			if _, err := throttled.Get(); nil != err {
+				stopped <- err
+				return
+			}
+			got <- time.Now()
+		}
+	}()
+	time.Sleep(time.Second)
+	throttled.Stop()
+
+	select {
+	case err := <-stopped:
+		if err != ErrStopped {
+			t.Fatalf("expected ErrStopped, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Get to return after Stop")
+	}
+	select {
+	case <-throttled.done:
+	default:
+		t.Fatal("expected Stop to wait for the token bucket goroutine")
+	}
+	close(got)
+	count := 0
+	for at := range got {
+		if at.Sub(start) <= time.Second {
+			count++
+		}
+	}
+	if count > rate+burst {
+		t.Fatalf("expected at most %d elements within a second, got %d", rate+burst, count)
+	}
+	if count < burst {
+		t.Fatalf("expected at least the %d burst elements, got %d", burst, count)
+	}
+	if _, err := throttled.Get(); err != ErrStopped {
+		t.Fatalf("expected ErrStopped after Stop, got %v", err)
+	}
+}
+
+func TestThrottleRejectsInvalidRate(t *testing.T) {
+	defer func() {
+		if nil == recover() {
+			t.Fatal("expected a panic for a non-positive rate")
+		}
+	}()
+	newIntSyncHeap(t).Throttle(0)
+}